@@ -0,0 +1,54 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"io"
+)
+
+func init() {
+	var (
+		f   framing
+		out string
+	)
+	commands = append(commands, &command{
+		name:     "cat",
+		args:     "[flags] file...",
+		synopsis: "concatenate streams",
+		flags: func(fs *flag.FlagSet) {
+			f.registerWrite(fs)
+			fs.StringVar(&out, "o", "-", "stream to write, or - for standard output")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() == 0 {
+				return errUsage
+			}
+			var srcs []io.Reader
+			for _, name := range fs.Args() {
+				in, err := openInput(name, s)
+				if err != nil {
+					return err
+				}
+				defer in.Close()
+				srcs = append(srcs, in)
+			}
+			return withOutput(out, s, func(w io.Writer) error {
+				_, err := f.copyOptions().Concat(w, srcs...)
+				return err
+			})
+		},
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestCat(t *testing.T) {
+	a, aData := writeRecords(t, 3)
+	b, bData := writeRecords(t, 5)
+	want := append(append([]*testdata.Record(nil), aData...), bData...)
+	code, stdout, stderr := runCmd(t, nil, "cat", a, b)
+	if code != 0 {
+		t.Fatalf("pbdelim cat %s %s exited %d; stderr:\n%s", a, b, code, stderr)
+	}
+	if got := readRecords(t, []byte(stdout)); !cmp.Equal(got, want, protocmp.Transform()) {
+		t.Errorf("pbdelim cat %s %s wrote %v, want %v", a, b, got, want)
+	}
+}
+
+func TestCatReframes(t *testing.T) {
+	var data []*testdata.Record
+	for i := 0; i < 10; i++ {
+		data = append(data, &testdata.Record{Third: proto.String(strings.Repeat("compressible ", i))})
+	}
+	var buf bytes.Buffer
+	w := pbutil.WriteDelimitedOptions{Compressed: true, Codec: pbutil.CodecGzip}.NewWriter(&buf)
+	for _, msg := range data {
+		if err := w.Write(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	in := filepath.Join(t.TempDir(), "gzip.bin")
+	if err := os.WriteFile(in, buf.Bytes(), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	args := []string{"cat", "-compressed", "-codec", "none", in}
+	code, stdout, stderr := runCmd(t, nil, args...)
+	if code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	r := pbutil.ReadDelimitedOptions{Compressed: true}.NewReader(strings.NewReader(stdout))
+	for i, want := range data {
+		got := new(testdata.Record)
+		if err := r.Next(got); err != nil {
+			t.Fatalf("r.Next(msg) for record %d = %v, want nil", i, err)
+		}
+		if !cmp.Equal(got, want, protocmp.Transform()) {
+			t.Errorf("record %d = %v, want %v", i, got, want)
+		}
+	}
+	// Every record is now stored under CodecNone.
+	rest := strings.NewReader(stdout)
+	for i := range data {
+		payload, _, err := pbutil.ReadDelimitedBytes(rest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := pbutil.Codec(payload[0]); got != pbutil.CodecNone {
+			t.Errorf("record %d stored with %v, want %v", i, got, pbutil.CodecNone)
+		}
+	}
+}
+
+func TestCatCodecFlag(t *testing.T) {
+	a, _ := writeRecords(t, 1)
+	if code, _, _ := runCmd(t, nil, "cat", "-codec", "lz4", a); code != 2 {
+		t.Errorf("pbdelim cat -codec lz4 %s exited %d, want 2", a, code)
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func init() {
+	var (
+		f      framing
+		sc     schema
+		format string
+		bare   bool
+	)
+	commands = append(commands, &command{
+		name:     "dump",
+		args:     "[flags] file",
+		synopsis: "print the records of a stream as text or JSON",
+		flags: func(fs *flag.FlagSet) {
+			f.register(fs)
+			sc.register(fs)
+			fs.StringVar(&format, "format", "text", "output format: text or json")
+			fs.BoolVar(&bare, "bare", false, "print one record per line without indices or offsets, as encode reads")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() != 1 {
+				return errUsage
+			}
+			p, err := newPrinter(format, bare)
+			if err != nil {
+				return err
+			}
+			in, err := openInput(fs.Arg(0), s)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			r, mt, err := openRecords(bufio.NewReader(in), f.readOptions(), &sc)
+			if err != nil {
+				return err
+			}
+			out := bufio.NewWriter(s.out)
+			if err := dump(out, r, mt, p); err != nil {
+				out.Flush()
+				return err
+			}
+			return out.Flush()
+		},
+	})
+}
+
+// openRecords returns a Reader over the records of in and their type, which
+// comes from sc if given and otherwise from the stream's descriptor header.
+func openRecords(in io.Reader, opts pbutil.ReadDelimitedOptions, sc *schema) (*pbutil.Reader, protoreflect.MessageType, error) {
+	if !sc.set() {
+		r, mt, err := opts.NewDescribedReader(in)
+		if err == pbutil.ErrNoDescriptorHeader {
+			err = fmt.Errorf("%w; use -descriptors and -type", err)
+		}
+		return r, mt, err
+	}
+	mt, err := sc.messageType()
+	if err != nil {
+		return nil, nil, err
+	}
+	return opts.NewReader(in), mt, nil
+}
+
+// printer formats records for dump.
+type printer struct {
+	json, bare bool
+}
+
+// newPrinter returns a printer for the named format.
+func newPrinter(format string, bare bool) (*printer, error) {
+	switch format {
+	case "text":
+		return &printer{bare: bare}, nil
+	case "json":
+		return &printer{json: true, bare: bare}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", errUsage, format)
+	}
+}
+
+// print writes the record m, which is the index'th record and occupies size
+// bytes at offset, to w.
+func (p *printer) print(w io.Writer, m proto.Message, index, offset, size int64) error {
+	switch {
+	case p.json:
+		b, err := protojson.Marshal(m)
+		if err != nil {
+			return err
+		}
+		if p.bare {
+			_, err = fmt.Fprintf(w, "%s\n", b)
+		} else {
+			_, err = fmt.Fprintf(w, "{\"index\":%d,\"offset\":%d,\"size\":%d,\"record\":%s}\n", index, offset, size, b)
+		}
+		return err
+	case p.bare:
+		b, err := prototext.Marshal(m)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	default:
+		b, err := prototext.MarshalOptions{Multiline: true}.Marshal(m)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "# record %d, offset %d, %d bytes\n%s\n", index, offset, size, b)
+		return err
+	}
+}
+
+// dump prints every record of r to w.
+func dump(w io.Writer, r *pbutil.Reader, mt protoreflect.MessageType, p *printer) error {
+	for {
+		index, offset := r.Index(), r.Offset()
+		m := mt.New().Interface()
+		switch err := r.Next(m); err {
+		case nil:
+		case io.EOF:
+			return nil
+		default:
+			return err
+		}
+		if err := p.print(w, m, index, offset, r.Offset()-offset); err != nil {
+			return err
+		}
+	}
+}
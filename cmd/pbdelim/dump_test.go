@@ -0,0 +1,227 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// writeDescriptors writes a FileDescriptorSet describing testdata.Record to a
+// new file in a temporary directory.
+func writeDescriptors(t *testing.T) string {
+	t.Helper()
+	fd := (*testdata.Record)(nil).ProtoReflect().Descriptor().ParentFile()
+	fds := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(fd)}}
+	b, err := proto.Marshal(fds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(t.TempDir(), "test.binpb")
+	if err := os.WriteFile(name, b, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// recordOffsets returns the offset of each of data's records in a stream.
+func recordOffsets(data []*testdata.Record) []int64 {
+	var offsets []int64
+	var offset int64
+	for _, msg := range data {
+		offsets = append(offsets, offset)
+		size := proto.Size(msg)
+		offset += int64(protowire.SizeVarint(uint64(size)) + size)
+	}
+	return offsets
+}
+
+var textHeader = regexp.MustCompile(`(?m)^# record (\d+), offset (\d+), (\d+) bytes$`)
+
+func TestDumpText(t *testing.T) {
+	name, data := writeRecords(t, 5)
+	desc := writeDescriptors(t)
+	args := []string{"dump", "-descriptors", desc, "-type", "testdata.Record", name}
+	code, stdout, stderr := runCmd(t, nil, args...)
+	if code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	headers := textHeader.FindAllStringSubmatch(stdout, -1)
+	bodies := textHeader.Split(stdout, -1)[1:]
+	if len(headers) != len(data) {
+		t.Fatalf("pbdelim %v printed %d records, want %d; stdout:\n%s", args, len(headers), len(data), stdout)
+	}
+	offsets := recordOffsets(data)
+	for i, want := range data {
+		size := proto.Size(want)
+		wantHeader := []string{headers[i][0], fmt.Sprint(i), fmt.Sprint(offsets[i]), fmt.Sprint(size + protowire.SizeVarint(uint64(size)))}
+		if !cmp.Equal(headers[i], wantHeader) {
+			t.Errorf("record %d header = %q, want %q", i, headers[i], wantHeader)
+		}
+		got := new(testdata.Record)
+		if err := prototext.Unmarshal([]byte(bodies[i]), got); err != nil {
+			t.Fatalf("prototext.Unmarshal(record %d) = %v, want nil", i, err)
+		}
+		if !cmp.Equal(got, want, protocmp.Transform()) {
+			t.Errorf("record %d = %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestDumpJSON(t *testing.T) {
+	name, data := writeRecords(t, 5)
+	desc := writeDescriptors(t)
+	args := []string{"dump", "-format", "json", "-descriptors", desc, "-type", "testdata.Record", name}
+	code, stdout, stderr := runCmd(t, nil, args...)
+	if code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != len(data) {
+		t.Fatalf("pbdelim %v printed %d lines, want %d; stdout:\n%s", args, len(lines), len(data), stdout)
+	}
+	offsets := recordOffsets(data)
+	for i, line := range lines {
+		var rec struct {
+			Index, Offset, Size int64
+			Record              json.RawMessage
+		}
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("json.Unmarshal(line %d) = %v, want nil", i, err)
+		}
+		if rec.Index != int64(i) || rec.Offset != offsets[i] {
+			t.Errorf("line %d index, offset = %d, %d; want %d, %d", i, rec.Index, rec.Offset, i, offsets[i])
+		}
+		got := new(testdata.Record)
+		if err := protojson.Unmarshal(rec.Record, got); err != nil {
+			t.Fatalf("protojson.Unmarshal(record %d) = %v, want nil", i, err)
+		}
+		if !cmp.Equal(got, data[i], protocmp.Transform()) {
+			t.Errorf("record %d = %v, want %v", i, got, data[i])
+		}
+	}
+}
+
+func TestDumpBare(t *testing.T) {
+	name, data := writeRecords(t, 3)
+	desc := writeDescriptors(t)
+	args := []string{"dump", "-bare", "-descriptors", desc, "-type", "testdata.Record", name}
+	code, stdout, stderr := runCmd(t, nil, args...)
+	if code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) != len(data) {
+		t.Fatalf("pbdelim %v printed %d lines, want %d; stdout:\n%s", args, len(lines), len(data), stdout)
+	}
+	for i, line := range lines {
+		got := new(testdata.Record)
+		if err := prototext.Unmarshal([]byte(line), got); err != nil {
+			t.Fatalf("prototext.Unmarshal(line %d) = %v, want nil", i, err)
+		}
+		if !cmp.Equal(got, data[i], protocmp.Transform()) {
+			t.Errorf("line %d = %v, want %v", i, got, data[i])
+		}
+	}
+}
+
+func TestDumpDescribed(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := pbutil.NewDescribedWriter(&buf, (*testdata.Record)(nil).ProtoReflect().Descriptor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &testdata.Record{First: proto.Uint64(7)}
+	if err := w.Write(want); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, stderr := runCmd(t, &buf, "dump", "-format", "json", "-bare", "-")
+	if code != 0 {
+		t.Fatalf("pbdelim dump - exited %d; stderr:\n%s", code, stderr)
+	}
+	got := new(testdata.Record)
+	if err := protojson.Unmarshal([]byte(stdout), got); err != nil {
+		t.Fatalf("protojson.Unmarshal(%q) = %v, want nil", stdout, err)
+	}
+	if !cmp.Equal(got, want, protocmp.Transform()) {
+		t.Errorf("pbdelim dump - printed %v, want %v", got, want)
+	}
+}
+
+func TestDumpErrors(t *testing.T) {
+	name, _ := writeRecords(t, 3)
+	desc := writeDescriptors(t)
+	corrupt := filepath.Join(t.TempDir(), "corrupt.bin")
+	if err := os.WriteFile(corrupt, []byte{2, 8, 1, 9, 8}, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name string
+		args []string
+		code int
+	}{
+		{
+			name: "no descriptor header",
+			args: []string{"dump", name},
+			code: 1,
+		},
+		{
+			name: "type without descriptors",
+			args: []string{"dump", "-type", "testdata.Record", name},
+			code: 2,
+		},
+		{
+			name: "unknown format",
+			args: []string{"dump", "-format", "yaml", "-descriptors", desc, "-type", "testdata.Record", name},
+			code: 2,
+		},
+		{
+			name: "unknown type",
+			args: []string{"dump", "-descriptors", desc, "-type", "testdata.Missing", name},
+			code: 1,
+		},
+		{
+			name: "corrupt stream",
+			args: []string{"dump", "-descriptors", desc, "-type", "testdata.Record", corrupt},
+			code: 1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if code, _, stderr := runCmd(t, nil, test.args...); code != test.code {
+				t.Errorf("pbdelim %v exited %d, want %d; stderr:\n%s", test.args, code, test.code, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func init() {
+	var (
+		f         framing
+		sc        schema
+		format    string
+		out       string
+		described bool
+	)
+	commands = append(commands, &command{
+		name:     "encode",
+		args:     "[flags] [file]",
+		synopsis: "encode newline-delimited text or JSON records as a stream",
+		flags: func(fs *flag.FlagSet) {
+			f.registerWrite(fs)
+			sc.register(fs)
+			fs.StringVar(&format, "format", "json", "input format: text or json")
+			fs.StringVar(&out, "o", "-", "stream to write, or - for standard output")
+			fs.BoolVar(&described, "described", false, "begin the stream with a descriptor header")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() > 1 {
+				return errUsage
+			}
+			inFormat, err := newInputFormat(format)
+			if err != nil {
+				return err
+			}
+			mt, err := sc.messageType()
+			if err != nil {
+				return err
+			}
+			name := "-"
+			if fs.NArg() == 1 {
+				name = fs.Arg(0)
+			}
+			in, err := openInput(name, s)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			return withOutput(out, s, func(w io.Writer) error {
+				return encode(w, bufio.NewReader(in), mt, inFormat, f.writeOptions(), described)
+			})
+		},
+	})
+}
+
+// inputFormat decodes lines of input into messages.
+type inputFormat struct {
+	unmarshal func(line []byte, m proto.Message) error
+	// skipBlank is whether blank lines are ignored rather than decoded.
+	skipBlank bool
+}
+
+// newInputFormat returns the named input format.  In JSON, blank lines are
+// ignored.  In text, every line is a record, so a blank line is an empty
+// message, matching the output of "pbdelim dump -bare".
+func newInputFormat(format string) (*inputFormat, error) {
+	switch format {
+	case "json":
+		return &inputFormat{unmarshal: protojson.Unmarshal, skipBlank: true}, nil
+	case "text":
+		return &inputFormat{unmarshal: prototext.Unmarshal}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q", errUsage, format)
+	}
+}
+
+// encode decodes each line of in as a record of type mt and writes the
+// records to w.  Identical input always produces identical output.
+func encode(w io.Writer, in *bufio.Reader, mt protoreflect.MessageType, f *inputFormat, opts pbutil.WriteDelimitedOptions, described bool) error {
+	var wr *pbutil.Writer
+	if described {
+		var err error
+		if wr, err = opts.NewDescribedWriter(w, mt.Descriptor()); err != nil {
+			return err
+		}
+	} else {
+		wr = opts.NewWriter(w)
+	}
+	var buf []byte
+	for lineno := 1; ; lineno++ {
+		line, err := in.ReadBytes('\n')
+		if err == io.EOF && len(line) == 0 {
+			break
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		line = bytes.TrimSuffix(bytes.TrimSuffix(line, []byte("\n")), []byte("\r"))
+		if f.skipBlank && len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		m := mt.New().Interface()
+		if err := f.unmarshal(line, m); err != nil {
+			wr.Flush()
+			return fmt.Errorf("line %d: %v", lineno, err)
+		}
+		// Dynamic messages otherwise marshal their fields in no particular
+		// order, which would make fixtures unstable.
+		payload, err := proto.MarshalOptions{Deterministic: true}.MarshalAppend(buf[:0], m)
+		if err != nil {
+			return fmt.Errorf("line %d: %v", lineno, err)
+		}
+		buf = payload
+		if err := wr.WriteBytes(payload); err != nil {
+			return err
+		}
+	}
+	return wr.Close()
+}
@@ -0,0 +1,227 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// readRecords decodes every Record of the stream b.
+func readRecords(t *testing.T, b []byte) []*testdata.Record {
+	t.Helper()
+	r := pbutil.NewReader(bytes.NewReader(b))
+	var got []*testdata.Record
+	for {
+		msg := new(testdata.Record)
+		err := r.Next(msg)
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatalf("r.Next(msg) for record %d = %v, want nil", len(got), err)
+		}
+		got = append(got, msg)
+	}
+}
+
+func TestEncode(t *testing.T) {
+	desc := writeDescriptors(t)
+	want := []*testdata.Record{
+		{First: proto.Uint64(1)},
+		{Third: proto.String("two")},
+		{},
+	}
+	for _, test := range []struct {
+		name  string
+		input string
+		args  []string
+	}{
+		{
+			name:  "json",
+			input: "{\"first\": \"1\"}\n\n{\"third\": \"two\"}\r\n{}",
+		},
+		{
+			name:  "text",
+			input: "first: 1\nthird: \"two\"\n\n",
+			args:  []string{"-format", "text"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"encode", "-descriptors", desc, "-type", "testdata.Record"}, test.args...)
+			code, stdout, stderr := runCmd(t, strings.NewReader(test.input), args...)
+			if code != 0 {
+				t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+			}
+			if got := readRecords(t, []byte(stdout)); !cmp.Equal(got, want, protocmp.Transform()) {
+				t.Errorf("pbdelim %v wrote %v, want %v", args, got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeRoundTrip(t *testing.T) {
+	name, _ := writeRecords(t, 10)
+	desc := writeDescriptors(t)
+	schema := []string{"-descriptors", desc, "-type", "testdata.Record"}
+	for _, format := range []string{"text", "json"} {
+		t.Run(format, func(t *testing.T) {
+			args := append([]string{"dump", "-bare", "-format", format}, append(schema, name)...)
+			code, text, stderr := runCmd(t, nil, args...)
+			if code != 0 {
+				t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+			}
+			out := filepath.Join(t.TempDir(), "out.bin")
+			args = append([]string{"encode", "-format", format, "-o", out}, schema...)
+			if code, _, stderr := runCmd(t, strings.NewReader(text), args...); code != 0 {
+				t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("dump then encode = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestEncodeDescribed(t *testing.T) {
+	desc := writeDescriptors(t)
+	args := []string{"encode", "-described", "-checksum", "-descriptors", desc, "-type", "testdata.Record"}
+	code, stream, stderr := runCmd(t, strings.NewReader(`{"first": "5"}`), args...)
+	if code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	r, mt, err := pbutil.ReadDelimitedOptions{Checksum: true}.NewDescribedReader(strings.NewReader(stream))
+	if err != nil {
+		t.Fatalf("NewDescribedReader(stream) = ?, ?, %v; want ?, ?, nil", err)
+	}
+	if got, want := string(mt.Descriptor().FullName()), "testdata.Record"; got != want {
+		t.Errorf("stream describes %s, want %s", got, want)
+	}
+	msg := mt.New().Interface()
+	if err := r.Next(msg); err != nil {
+		t.Fatalf("r.Next(msg) = %v, want nil", err)
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	desc := writeDescriptors(t)
+	for _, test := range []struct {
+		name   string
+		input  string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{
+			name:   "malformed line",
+			input:  "{\"first\": \"1\"}\n{\"first\": \n",
+			args:   []string{"encode", "-descriptors", desc, "-type", "testdata.Record"},
+			code:   1,
+			stderr: "line 2",
+		},
+		{
+			name:   "unknown field",
+			input:  "second: 2\n",
+			args:   []string{"encode", "-format", "text", "-descriptors", desc, "-type", "testdata.Record"},
+			code:   1,
+			stderr: "line 1",
+		},
+		{
+			name:   "missing schema",
+			args:   []string{"encode"},
+			code:   2,
+			stderr: "usage:",
+		},
+		{
+			name:   "too many arguments",
+			args:   []string{"encode", "-descriptors", desc, "-type", "testdata.Record", "a", "b"},
+			code:   2,
+			stderr: "usage:",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			code, _, stderr := runCmd(t, strings.NewReader(test.input), test.args...)
+			if code != test.code {
+				t.Errorf("pbdelim %v exited %d, want %d; stderr:\n%s", test.args, code, test.code, stderr)
+			}
+			if !strings.Contains(stderr, test.stderr) {
+				t.Errorf("pbdelim %v printed %q to stderr, want it to contain %q", test.args, stderr, test.stderr)
+			}
+		})
+	}
+}
+
+func TestEncodeCompressed(t *testing.T) {
+	desc := writeDescriptors(t)
+	input := fmt.Sprintf("{\"third\": %q}\n", strings.Repeat("x", 100))
+	want := []*testdata.Record{{Third: proto.String(strings.Repeat("x", 100))}}
+	for _, test := range []struct {
+		name  string
+		args  []string
+		codec pbutil.Codec
+	}{
+		{name: "default codec", codec: pbutil.CodecFlate},
+		{name: "gzip", args: []string{"-codec", "gzip"}, codec: pbutil.CodecGzip},
+		{name: "none", args: []string{"-codec", "none"}, codec: pbutil.CodecNone},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			args := append([]string{"encode", "-descriptors", desc, "-type", "testdata.Record", "-compressed"}, test.args...)
+			code, stdout, stderr := runCmd(t, strings.NewReader(input), args...)
+			if code != 0 {
+				t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+			}
+			stored, _, err := pbutil.ReadDelimitedBytes(strings.NewReader(stdout))
+			if err != nil || len(stored) == 0 {
+				t.Fatalf("ReadDelimitedBytes(output) = %v, ?, %v; want a payload, ?, nil", stored, err)
+			}
+			if got := pbutil.Codec(stored[0]); got != test.codec {
+				t.Errorf("pbdelim %v stored the record under %v, want %v", args, got, test.codec)
+			}
+			var got []*testdata.Record
+			r := pbutil.ReadDelimitedOptions{Compressed: true}.NewReader(strings.NewReader(stdout))
+			for {
+				msg := new(testdata.Record)
+				if err := r.Next(msg); err == io.EOF {
+					break
+				} else if err != nil {
+					t.Fatalf("r.Next(msg) = %v, want nil", err)
+				}
+				got = append(got, msg)
+			}
+			if !cmp.Equal(got, want, protocmp.Transform()) {
+				t.Errorf("pbdelim %v wrote %v, want %v", args, got, want)
+			}
+		})
+	}
+}
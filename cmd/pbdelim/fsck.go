@@ -0,0 +1,85 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+)
+
+func init() {
+	var (
+		f        framing
+		sc       schema
+		required bool
+	)
+	commands = append(commands, &command{
+		name:     "fsck",
+		args:     "[flags] file",
+		synopsis: "verify the framing and payloads of a stream",
+		flags: func(fs *flag.FlagSet) {
+			f.register(fs)
+			sc.register(fs)
+			fs.BoolVar(&required, "required", false, "report records lacking required fields; needs -descriptors and -type")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() != 1 {
+				return errUsage
+			}
+			opts := pbutil.VerifyOptions{Framing: f.readOptions(), CheckRequired: required}
+			if sc.set() || required {
+				mt, err := sc.messageType()
+				if err != nil {
+					return err
+				}
+				opts.Type = mt
+			}
+			in, err := openInput(fs.Arg(0), s)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			report, err := opts.Verify(in)
+			printReport(s.out, report)
+			if err != nil {
+				return fmt.Errorf("record %d: %v", report.Records, err)
+			}
+			fmt.Fprintln(s.out, "ok")
+			return nil
+		},
+	})
+}
+
+// printReport writes the counts and size histogram of report to w.
+func printReport(w io.Writer, report *pbutil.VerifyReport) {
+	fmt.Fprintf(w, "records: %d\nbytes:   %d\n", report.Records, report.Bytes)
+	if len(report.Sizes) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "payload sizes:")
+	for i, n := range report.Sizes {
+		if n == 0 {
+			continue
+		}
+		bucket := "0"
+		if i > 0 {
+			bucket = fmt.Sprintf("[%d, %d)", uint64(1)<<(i-1), uint64(1)<<i)
+		}
+		fmt.Fprintf(w, "  %-24s %d\n", bucket, n)
+	}
+}
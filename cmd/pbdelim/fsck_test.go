@@ -0,0 +1,101 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFsck(t *testing.T) {
+	name, _ := writeRecords(t, 10)
+	desc := writeDescriptors(t)
+	good, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := filepath.Join(t.TempDir(), "truncated.bin")
+	if err := os.WriteFile(truncated, good[:len(good)-1], 0o666); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if _, err := pbutil.WriteDelimited(&buf, &testdata.Record{Third: proto.String("no first")}); err != nil {
+		t.Fatal(err)
+	}
+	partial := filepath.Join(t.TempDir(), "partial.bin")
+	if err := os.WriteFile(partial, buf.Bytes(), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		name   string
+		args   []string
+		code   int
+		stdout []string
+		stderr string
+	}{
+		{
+			name:   "well-formed",
+			args:   []string{"fsck", name},
+			stdout: []string{"records: 10\n", "bytes:   " + strconv.Itoa(len(good)) + "\n", "[8, 16)", "ok\n"},
+		},
+		{
+			name:   "well-formed with type",
+			args:   []string{"fsck", "-required", "-descriptors", desc, "-type", "testdata.Record", name},
+			stdout: []string{"records: 10\n", "ok\n"},
+		},
+		{
+			name:   "required fields",
+			args:   []string{"fsck", "-required", "-descriptors", desc, "-type", "testdata.Required", partial},
+			code:   1,
+			stdout: []string{"records: 0\n"},
+			stderr: "record 0: pbutil: record at offset 0",
+		},
+		{
+			name:   "truncated",
+			args:   []string{"fsck", truncated},
+			code:   1,
+			stdout: []string{"records: 9\n"},
+			stderr: "unexpected EOF",
+		},
+		{
+			name: "required without type",
+			args: []string{"fsck", "-required", name},
+			code: 2,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(t, nil, test.args...)
+			if code != test.code {
+				t.Fatalf("pbdelim %v exited %d, want %d; stderr:\n%s", test.args, code, test.code, stderr)
+			}
+			for _, want := range test.stdout {
+				if !strings.Contains(stdout, want) {
+					t.Errorf("pbdelim %v printed %q, want it to contain %q", test.args, stdout, want)
+				}
+			}
+			if !strings.Contains(stderr, test.stderr) {
+				t.Errorf("pbdelim %v printed %q to stderr, want it to contain %q", test.args, stderr, test.stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+)
+
+func init() {
+	var (
+		f   framing
+		n   int64
+		out string
+	)
+	commands = append(commands, &command{
+		name:     "head",
+		args:     "[flags] [file]",
+		synopsis: "copy the first records of a stream",
+		flags: func(fs *flag.FlagSet) {
+			f.registerWrite(fs)
+			fs.Int64Var(&n, "n", 10, "number of records to copy")
+			fs.StringVar(&out, "o", "-", "stream to write, or - for standard output")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() > 1 || n < 0 {
+				return errUsage
+			}
+			name := "-"
+			if fs.NArg() == 1 {
+				name = fs.Arg(0)
+			}
+			in, err := openInput(name, s)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			return withOutput(out, s, func(w io.Writer) error {
+				_, err := f.copyOptions().Copy(w, bufio.NewReader(in), n)
+				return err
+			})
+		},
+	})
+}
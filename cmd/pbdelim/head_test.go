@@ -0,0 +1,81 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestHead(t *testing.T) {
+	name, data := writeRecords(t, 20)
+	for _, test := range []struct {
+		name string
+		args []string
+		want []*testdata.Record
+	}{
+		{
+			name: "default",
+			args: []string{"head", name},
+			want: data[:10],
+		},
+		{
+			name: "count",
+			args: []string{"head", "-n", "3", name},
+			want: data[:3],
+		},
+		{
+			name: "more than all",
+			args: []string{"head", "-n", "100", name},
+			want: data,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			code, stdout, stderr := runCmd(t, nil, test.args...)
+			if code != 0 {
+				t.Fatalf("pbdelim %v exited %d; stderr:\n%s", test.args, code, stderr)
+			}
+			if got := readRecords(t, []byte(stdout)); !cmp.Equal(got, test.want, protocmp.Transform()) {
+				t.Errorf("pbdelim %v wrote %v, want %v", test.args, got, test.want)
+			}
+		})
+	}
+}
+
+func TestHeadStdinToFile(t *testing.T) {
+	name, data := writeRecords(t, 5)
+	stream, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "head.bin")
+	args := []string{"head", "-n", "2", "-o", out}
+	if code, _, stderr := runCmd(t, strings.NewReader(string(stream)), args...); code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	got, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := data[:2]; !cmp.Equal(readRecords(t, got), want, protocmp.Transform()) {
+		t.Errorf("pbdelim %v wrote %v, want %v", args, readRecords(t, got), want)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+)
+
+func init() {
+	var (
+		f   framing
+		out string
+	)
+	commands = append(commands, &command{
+		name:     "index",
+		args:     "[flags] file",
+		synopsis: "build a sidecar record index for an existing stream",
+		flags: func(fs *flag.FlagSet) {
+			f.register(fs)
+			fs.StringVar(&out, "o", "", "index file to write (default: file.idx)")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() != 1 {
+				return errUsage
+			}
+			name := fs.Arg(0)
+			if out == "" {
+				out = name + ".idx"
+			}
+			idx, err := buildIndex(name, f.readOptions())
+			if err != nil {
+				return err
+			}
+			enc, err := idx.MarshalBinary()
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(out, enc, 0o666); err != nil {
+				return err
+			}
+			fmt.Fprintf(s.out, "%s: indexed %d records in %d bytes\n", out, idx.Len(), idx.End)
+			return nil
+		},
+	})
+}
+
+// buildIndex indexes the records of the named file.
+func buildIndex(name string, opts pbutil.ReadDelimitedOptions) (*pbutil.Index, error) {
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return opts.BuildIndex(file)
+}
@@ -0,0 +1,78 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestIndex(t *testing.T) {
+	name, data := writeRecords(t, 20)
+	if code, _, stderr := runCmd(t, nil, "index", name); code != 0 {
+		t.Fatalf("pbdelim index %s exited %d; stderr:\n%s", name, code, stderr)
+	}
+	enc, err := os.ReadFile(name + ".idx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	idx := new(pbutil.Index)
+	if err := idx.UnmarshalBinary(enc); err != nil {
+		t.Fatalf("UnmarshalBinary(sidecar) = %v, want nil", err)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r := pbutil.NewIndexedReader(file, idx)
+	if got, want := r.Len(), len(data); got != want {
+		t.Fatalf("sidecar indexes %d records, want %d", got, want)
+	}
+	var msg testdata.Record
+	if err := r.RecordAt(13, &msg); err != nil {
+		t.Fatalf("r.RecordAt(13, &msg) = %v, want nil", err)
+	}
+	if !cmp.Equal(&msg, data[13], protocmp.Transform()) {
+		t.Errorf("r.RecordAt(13, &msg); msg = %v, want %v", &msg, data[13])
+	}
+}
+
+func TestIndexOutputFlag(t *testing.T) {
+	name, _ := writeRecords(t, 3)
+	out := filepath.Join(t.TempDir(), "custom.idx")
+	if code, _, stderr := runCmd(t, nil, "index", "-o", out, name); code != 0 {
+		t.Fatalf("pbdelim index -o %s %s exited %d; stderr:\n%s", out, name, code, stderr)
+	}
+	if _, err := os.Stat(out); err != nil {
+		t.Errorf("pbdelim index -o %s did not write the index: %v", out, err)
+	}
+}
+
+func TestIndexCorrupt(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "corrupt.bin")
+	if err := os.WriteFile(name, []byte{2, 8, 1, 9, 8}, 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if code, _, _ := runCmd(t, nil, "index", name); code != 1 {
+		t.Errorf("pbdelim index %s exited %d, want 1", name, code)
+	}
+}
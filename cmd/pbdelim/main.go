@@ -0,0 +1,229 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command pbdelim inspects and manipulates length-delimited Protocol Buffer
+// record streams, as produced by pbutil.WriteDelimited.
+//
+// Usage:
+//
+//	pbdelim <command> [flags] [arguments]
+//
+// Run "pbdelim help" for the list of commands, and "pbdelim <command> -h" for
+// the flags of each.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// stdio holds the standard streams available to a command.
+type stdio struct {
+	in       io.Reader
+	out, err io.Writer
+}
+
+// command is a pbdelim subcommand.
+type command struct {
+	name     string
+	args     string
+	synopsis string
+	// run executes the command with its flags already parsed into fs.
+	run   func(fs *flag.FlagSet, s stdio) error
+	flags func(fs *flag.FlagSet)
+}
+
+// commands lists the available subcommands in the order that help shows them.
+var commands []*command
+
+// errUsage indicates that a command was invoked incorrectly.
+var errUsage = errors.New("usage error")
+
+func main() {
+	os.Exit(run(os.Args[1:], stdio{in: os.Stdin, out: os.Stdout, err: os.Stderr}))
+}
+
+// run executes the command line args and returns the process exit code: 0 on
+// success, 1 on failure, and 2 on incorrect usage.
+func run(args []string, s stdio) int {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(s.err)
+		if len(args) == 0 {
+			return 2
+		}
+		return 0
+	}
+	var cmd *command
+	for _, c := range commands {
+		if c.name == args[0] {
+			cmd = c
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(s.err, "pbdelim: unknown command %q\n", args[0])
+		usage(s.err)
+		return 2
+	}
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.SetOutput(s.err)
+	fs.Usage = func() {
+		fmt.Fprintf(s.err, "usage: pbdelim %s %s\n\n%s.\n", cmd.name, cmd.args, cmd.synopsis)
+		fs.PrintDefaults()
+	}
+	if cmd.flags != nil {
+		cmd.flags(fs)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if err := cmd.run(fs, s); err != nil {
+		if errors.Is(err, errUsage) {
+			if err != errUsage {
+				fmt.Fprintf(s.err, "pbdelim %s: %v\n", cmd.name, err)
+			}
+			fs.Usage()
+			return 2
+		}
+		fmt.Fprintf(s.err, "pbdelim %s: %v\n", cmd.name, err)
+		return 1
+	}
+	return 0
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "usage: pbdelim <command> [flags] [arguments]\n\ncommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.synopsis)
+	}
+}
+
+// framing holds the flags that select the framing of a record stream.
+type framing struct {
+	checksum, compressed bool
+	codec                codecValue
+}
+
+// register adds the framing flags to fs.
+func (f *framing) register(fs *flag.FlagSet) {
+	fs.BoolVar(&f.checksum, "checksum", false, "records carry CRC-32C checksums")
+	fs.BoolVar(&f.compressed, "compressed", false, "records carry codec bytes and may be compressed")
+}
+
+// registerWrite adds the framing flags to fs along with those that only apply
+// to commands that write records.
+func (f *framing) registerWrite(fs *flag.FlagSet) {
+	f.register(fs)
+	f.codec = codecValue(pbutil.CodecFlate)
+	fs.Var(&f.codec, "codec", "codec for records written with -compressed: none, gzip, or flate")
+}
+
+// readOptions returns the ReadDelimitedOptions matching the framing.
+func (f *framing) readOptions() pbutil.ReadDelimitedOptions {
+	return pbutil.ReadDelimitedOptions{Checksum: f.checksum, Compressed: f.compressed}
+}
+
+// writeOptions returns the WriteDelimitedOptions matching the framing.
+func (f *framing) writeOptions() pbutil.WriteDelimitedOptions {
+	return pbutil.WriteDelimitedOptions{Checksum: f.checksum, Compressed: f.compressed, Codec: pbutil.Codec(f.codec)}
+}
+
+// copyOptions returns the CopyOptions that preserve the framing.
+func (f *framing) copyOptions() pbutil.CopyOptions {
+	return pbutil.CopyOptions{Read: f.readOptions(), Write: f.writeOptions()}
+}
+
+// codecValue is a flag.Value naming a built-in pbutil.Codec.
+type codecValue pbutil.Codec
+
+func (c *codecValue) String() string { return pbutil.Codec(*c).String() }
+
+func (c *codecValue) Set(s string) error {
+	for _, codec := range []pbutil.Codec{pbutil.CodecNone, pbutil.CodecGzip, pbutil.CodecFlate} {
+		if codec.String() == s {
+			*c = codecValue(codec)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown codec %q", s)
+}
+
+// schema holds the flags that name the message type of a stream's records.
+type schema struct {
+	descriptors, typeName string
+}
+
+// register adds the schema flags to fs.
+func (sc *schema) register(fs *flag.FlagSet) {
+	fs.StringVar(&sc.descriptors, "descriptors", "", "binary FileDescriptorSet (.binpb or .protoset) describing the records")
+	fs.StringVar(&sc.typeName, "type", "", "full name of the records' message type")
+}
+
+// set reports whether either schema flag was given.
+func (sc *schema) set() bool {
+	return sc.descriptors != "" || sc.typeName != ""
+}
+
+// messageType loads the descriptor set and resolves the named type.  Both
+// flags are required.
+func (sc *schema) messageType() (protoreflect.MessageType, error) {
+	if sc.descriptors == "" || sc.typeName == "" {
+		return nil, fmt.Errorf("%w: -descriptors and -type must be given together", errUsage)
+	}
+	b, err := os.ReadFile(sc.descriptors)
+	if err != nil {
+		return nil, err
+	}
+	fds := new(descriptorpb.FileDescriptorSet)
+	if err := proto.Unmarshal(b, fds); err != nil {
+		return nil, fmt.Errorf("%s: %v", sc.descriptors, err)
+	}
+	return pbutil.MessageTypeFromSet(fds, protoreflect.FullName(sc.typeName))
+}
+
+// openInput opens the named file for reading, or returns standard input for
+// "-".
+func openInput(name string, s stdio) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(s.in), nil
+	}
+	return os.Open(name)
+}
+
+// withOutput calls write with the named file, or with standard output for
+// "-", and closes the file afterward.
+func withOutput(name string, s stdio, write func(w io.Writer) error) error {
+	if name == "-" {
+		return write(s.out)
+	}
+	file, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = write(file)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
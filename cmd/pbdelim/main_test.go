@@ -0,0 +1,126 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/matttproud/golang_protobuf_extensions/v2/pbutil"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+// runCmd runs pbdelim with args and stdin, returning the exit code and the
+// contents of standard output and standard error.
+func runCmd(t *testing.T, stdin io.Reader, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	if stdin == nil {
+		stdin = strings.NewReader("")
+	}
+	var out, errOut bytes.Buffer
+	code = run(args, stdio{in: stdin, out: &out, err: &errOut})
+	return code, out.String(), errOut.String()
+}
+
+// writeRecords writes n Records of growing size to a new file in a temporary
+// directory.  The records match those of pbutil's own test fixtures, which
+// package main cannot import.
+func writeRecords(t *testing.T, n int) (name string, data []*testdata.Record) {
+	t.Helper()
+	var buf bytes.Buffer
+	for i := 0; i < n; i++ {
+		msg := &testdata.Record{First: proto.Uint64(uint64(i)), Third: proto.String(strings.Repeat("x", i))}
+		if _, err := pbutil.WriteDelimited(&buf, msg); err != nil {
+			t.Fatalf("WriteDelimited(buf, %v) = ?, %v; want ?, nil", msg, err)
+		}
+		data = append(data, msg)
+	}
+	name = filepath.Join(t.TempDir(), "records.bin")
+	if err := os.WriteFile(name, buf.Bytes(), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	return name, data
+}
+
+func TestRunUsage(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		args []string
+		code int
+	}{
+		{
+			name: "no arguments",
+			code: 2,
+		},
+		{
+			name: "help",
+			args: []string{"help"},
+			code: 0,
+		},
+		{
+			name: "unknown command",
+			args: []string{"frobnicate"},
+			code: 2,
+		},
+		{
+			name: "missing argument",
+			args: []string{"index"},
+			code: 2,
+		},
+		{
+			name: "unknown flag",
+			args: []string{"index", "-frobnicate", "file"},
+			code: 2,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			code, _, stderr := runCmd(t, nil, test.args...)
+			if code != test.code {
+				t.Errorf("pbdelim %v exited %d, want %d; stderr:\n%s", test.args, code, test.code, stderr)
+			}
+			if !strings.Contains(stderr, "usage:") {
+				t.Errorf("pbdelim %v printed %q to stderr, want usage", test.args, stderr)
+			}
+		})
+	}
+}
+
+func TestCodecFlagOnlyForWriters(t *testing.T) {
+	for _, test := range []struct {
+		command string
+		writes  bool
+	}{
+		{command: "cat", writes: true},
+		{command: "dump"},
+		{command: "encode", writes: true},
+		{command: "fsck"},
+		{command: "head", writes: true},
+		{command: "index"},
+		{command: "sample", writes: true},
+		{command: "split", writes: true},
+	} {
+		t.Run(test.command, func(t *testing.T) {
+			_, _, stderr := runCmd(t, nil, test.command, "-h")
+			if got := strings.Contains(stderr, "-codec"); got != test.writes {
+				t.Errorf("pbdelim %s -h lists -codec = %v, want %v; stderr:\n%s", test.command, got, test.writes, stderr)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"io"
+	"math/rand"
+	"time"
+)
+
+func init() {
+	var (
+		f    framing
+		k    int
+		seed int64
+		out  string
+	)
+	commands = append(commands, &command{
+		name:     "sample",
+		args:     "[flags] [file]",
+		synopsis: "copy a uniform random sample of a stream's records",
+		flags: func(fs *flag.FlagSet) {
+			f.registerWrite(fs)
+			fs.IntVar(&k, "k", 10, "number of records to sample")
+			fs.Int64Var(&seed, "seed", 0, "random seed for a reproducible sample (default: time-based)")
+			fs.StringVar(&out, "o", "-", "stream to write, or - for standard output")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() > 1 || k < 0 {
+				return errUsage
+			}
+			name := "-"
+			if fs.NArg() == 1 {
+				name = fs.Arg(0)
+			}
+			if seed == 0 {
+				seed = time.Now().UnixNano()
+			}
+			in, err := openInput(name, s)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			rng := rand.New(rand.NewSource(seed))
+			return withOutput(out, s, func(w io.Writer) error {
+				_, err := f.copyOptions().Sample(w, in, k, rng)
+				return err
+			})
+		},
+	})
+}
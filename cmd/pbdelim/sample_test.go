@@ -0,0 +1,47 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestSample(t *testing.T) {
+	name, data := writeRecords(t, 50)
+	args := []string{"sample", "-k", "5", "-seed", "7", name}
+	code, first, stderr := runCmd(t, nil, args...)
+	if code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	got := readRecords(t, []byte(first))
+	if len(got) != 5 {
+		t.Fatalf("pbdelim %v wrote %d records, want 5", args, len(got))
+	}
+	prev := -1
+	for _, msg := range got {
+		i := int(msg.GetFirst())
+		if i <= prev || !cmp.Equal(msg, data[i], protocmp.Transform()) {
+			t.Errorf("pbdelim %v wrote %v after record %d, want a later record of the input", args, msg, prev)
+		}
+		prev = i
+	}
+	// The same seed yields the same sample.
+	if _, second, _ := runCmd(t, nil, args...); second != first {
+		t.Errorf("pbdelim %v is not reproducible", args)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func init() {
+	var (
+		f      framing
+		size   int64
+		prefix string
+	)
+	commands = append(commands, &command{
+		name:     "split",
+		args:     "[flags] file",
+		synopsis: "split a stream into parts of bounded size",
+		flags: func(fs *flag.FlagSet) {
+			f.registerWrite(fs)
+			fs.Int64Var(&size, "size", 0, "largest part to write, in bytes; a larger record occupies a part by itself")
+			fs.StringVar(&prefix, "prefix", "", "prefix of the part names, which end in a four-digit part number (default: file.)")
+		},
+		run: func(fs *flag.FlagSet, s stdio) error {
+			if fs.NArg() != 1 || size <= 0 {
+				return errUsage
+			}
+			name := fs.Arg(0)
+			if prefix == "" {
+				prefix = name + "."
+			}
+			in, err := openInput(name, s)
+			if err != nil {
+				return err
+			}
+			defer in.Close()
+			_, err = f.copyOptions().Split(in, size, func(part int) (io.WriteCloser, error) {
+				name := fmt.Sprintf("%s%04d", prefix, part)
+				fmt.Fprintln(s.out, name)
+				return os.Create(name)
+			})
+			return err
+		},
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestSplit(t *testing.T) {
+	name, data := writeRecords(t, 40)
+	prefix := filepath.Join(t.TempDir(), "part-")
+	args := []string{"split", "-size", "100", "-prefix", prefix, name}
+	code, stdout, stderr := runCmd(t, nil, args...)
+	if code != 0 {
+		t.Fatalf("pbdelim %v exited %d; stderr:\n%s", args, code, stderr)
+	}
+	parts := strings.Fields(stdout)
+	if len(parts) < 2 || parts[0] != prefix+"0000" {
+		t.Fatalf("pbdelim %v wrote parts %v, want several beginning with %s0000", args, parts, prefix)
+	}
+	var got []*testdata.Record
+	for _, part := range parts {
+		b, err := os.ReadFile(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		records := readRecords(t, b)
+		if len(records) > 1 && len(b) > 100 {
+			t.Errorf("%s holds %d records in %d bytes, want at most 100 bytes", part, len(records), len(b))
+		}
+		got = append(got, records...)
+	}
+	if !cmp.Equal(got, data, protocmp.Transform()) {
+		t.Errorf("pbdelim %v parts hold %v, want %v", args, got, data)
+	}
+}
+
+func TestSplitUsage(t *testing.T) {
+	name, _ := writeRecords(t, 1)
+	if code, _, _ := runCmd(t, nil, "split", name); code != 2 {
+		t.Errorf("pbdelim split %s exited %d, want 2 without -size", name, code)
+	}
+}
@@ -17,6 +17,8 @@ package pbutil
 import (
 	"bytes"
 	"errors"
+	"io"
+	"strings"
 	"testing"
 
 	"google.golang.org/protobuf/proto"
@@ -26,6 +28,38 @@ import (
 	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
 )
 
+// recordWriter is implemented by each of the package's record writers.
+type recordWriter interface {
+	Write(m proto.Message) error
+	Close() error
+}
+
+// writeFixture writes n Records, built by record, with a writer from
+// newWriter and returns the encoded stream alongside the records.
+func writeFixture[W recordWriter](t *testing.T, n int, newWriter func(io.Writer) W, record func(i int) *testdata.Record) ([]byte, []*testdata.Record) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := newWriter(&buf)
+	var data []*testdata.Record
+	for i := 0; i < n; i++ {
+		msg := record(i)
+		if err := w.Write(msg); err != nil {
+			t.Fatalf("w.Write(record %d) = %v, want nil", i, err)
+		}
+		data = append(data, msg)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+	return buf.Bytes(), data
+}
+
+// growingRecord returns the i-th Record of a fixture whose payloads grow in
+// size.
+func growingRecord(i int) *testdata.Record {
+	return &testdata.Record{First: proto.Uint64(uint64(i)), Third: proto.String(strings.Repeat("x", i))}
+}
+
 func TestWriteDelimited(t *testing.T) {
 	for _, test := range []struct {
 		name string
@@ -0,0 +1,294 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// A block container batches many records into compressed blocks, which
+// compress far better than records do individually.  Each block is laid out
+// as follows:
+//
+//	varint   number of records in the block
+//	varint   size in bytes of the uncompressed block data
+//	uint32   CRC-32C of the uncompressed block data, little-endian
+//	varint   size in bytes of the stored block data
+//	byte     Codec of the stored block data
+//	...      stored block data
+//
+// The uncompressed block data is itself an ordinary length-delimited stream,
+// as produced by WriteDelimited, holding the block's records.
+
+const defaultBlockSize = 64 << 10
+
+// BlockOptions configures the reading and writing of block containers.
+type BlockOptions struct {
+	// BlockSize is the uncompressed size in bytes at which a BlockWriter ends
+	// the current block.  Blocks may exceed it by up to one record.  Zero
+	// selects a default of 64 KiB.
+	BlockSize int
+
+	// Codec selects the compression applied to each block.  Blocks that
+	// compression would make larger are stored under CodecNone.  The zero
+	// value, CodecNone, stores blocks uncompressed.
+	Codec Codec
+
+	// MaxBlockSize is the largest uncompressed block a BlockReader accepts.
+	// Zero or less imposes no limit beyond the 2 GiB ceiling of the wire
+	// format.
+	MaxBlockSize int64
+}
+
+// BlockWriter writes records to a block container.  Callers must call Flush or
+// Close once they are done writing to ensure that the final block is written.
+type BlockWriter struct {
+	opts   BlockOptions
+	w      io.Writer
+	data   []byte
+	stored []byte
+	count  int
+	err    error
+	closed bool
+}
+
+// NewBlockWriter returns a BlockWriter that writes flate-compressed blocks of
+// the default size to w.
+func NewBlockWriter(w io.Writer) *BlockWriter {
+	return BlockOptions{Codec: CodecFlate}.NewBlockWriter(w)
+}
+
+// NewBlockWriter returns a BlockWriter that writes blocks to w according to o.
+func (o BlockOptions) NewBlockWriter(w io.Writer) *BlockWriter {
+	if o.BlockSize <= 0 {
+		o.BlockSize = defaultBlockSize
+	}
+	return &BlockWriter{opts: o, w: w}
+}
+
+// Write appends m to the current block, writing the block out if it has
+// reached the configured size.  If m fails to marshal, nothing is written and
+// the BlockWriter remains usable.  Errors from the underlying io.Writer are
+// sticky.
+func (w *BlockWriter) Write(m proto.Message) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	start := len(w.data)
+	size := proto.Size(m)
+	w.data = protowire.AppendVarint(w.data, uint64(size))
+	data, err := proto.MarshalOptions{UseCachedSize: true}.MarshalAppend(w.data, m)
+	if err != nil {
+		w.data = w.data[:start]
+		return err
+	}
+	w.data = data
+	w.count++
+	if len(w.data) >= w.opts.BlockSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush ends the current block and writes it to the underlying io.Writer.  It
+// does nothing if the current block is empty.
+func (w *BlockWriter) Flush() error {
+	if w.err != nil || w.count == 0 {
+		return w.err
+	}
+	stored, err := compressPayload(w.stored[:0], w.data, w.opts.Codec)
+	if err != nil {
+		w.err = err
+		return err
+	}
+	w.stored = stored
+	var arr [3*binary.MaxVarintLen64 + checksumLen]byte
+	hdr := protowire.AppendVarint(arr[:0], uint64(w.count))
+	hdr = protowire.AppendVarint(hdr, uint64(len(w.data)))
+	hdr = appendChecksum(hdr, w.data)
+	hdr = protowire.AppendVarint(hdr, uint64(len(stored)))
+	if _, err := w.w.Write(hdr); err != nil {
+		w.err = err
+		return err
+	}
+	if _, err := w.w.Write(stored); err != nil {
+		w.err = err
+		return err
+	}
+	w.data = w.data[:0]
+	w.count = 0
+	return nil
+}
+
+// Close writes any buffered records as a final block and prevents further
+// writes.  It does not close the underlying io.Writer.
+func (w *BlockWriter) Close() error {
+	w.closed = true
+	return w.Flush()
+}
+
+// Block is a decoded block of a block container.
+type Block struct {
+	// Offset is the position of the block's first byte in the container.
+	Offset int64
+	// Records is the number of records held in Data.
+	Records int
+	// Data holds the block's records as an uncompressed length-delimited
+	// stream.
+	Data []byte
+}
+
+// Reader returns a Reader over the records of the block.
+func (b *Block) Reader() *Reader {
+	return NewReader(bytes.NewReader(b.Data))
+}
+
+// BlockReader reads records from a block container, either one record at a
+// time or one block at a time.
+type BlockReader struct {
+	opts   BlockOptions
+	cr     countingReader
+	offset int64
+	s      scratch
+	block  Block
+	inner  *Reader
+	active bool
+	br     bytes.Reader
+}
+
+// NewBlockReader returns a BlockReader that reads blocks from r with the
+// default options.
+func NewBlockReader(r io.Reader) *BlockReader {
+	return BlockOptions{}.NewBlockReader(r)
+}
+
+// NewBlockReader returns a BlockReader that reads blocks from r according to
+// o.
+func (o BlockOptions) NewBlockReader(r io.Reader) *BlockReader {
+	return &BlockReader{opts: o, cr: countingReader{r: r}}
+}
+
+// NextBlock reads, verifies, and decompresses the next block.  Any records of
+// the current block that have not yet been returned by Next are skipped.  The
+// returned Block, including its Data, is only valid until the next call to a
+// method of the BlockReader.  It returns io.EOF, unwrapped, once the
+// container ends cleanly on a block boundary.
+func (r *BlockReader) NextBlock() (*Block, error) {
+	r.active = false
+	start := r.offset
+	r.cr.n = 0
+	err := r.readBlock(start)
+	r.offset += int64(r.cr.n)
+	if err != nil {
+		return nil, err
+	}
+	return &r.block, nil
+}
+
+// readBlock reads the block beginning at offset into r.block.
+func (r *BlockReader) readBlock(offset int64) error {
+	count, err := readHeader(&r.cr)
+	if err != nil {
+		return frameError(offset, StageHeader, 0, err)
+	}
+	size, err := readHeader(&r.cr)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return frameError(offset, StageHeader, 0, err)
+	}
+	var trailer [checksumLen]byte
+	if _, err := io.ReadFull(&r.cr, trailer[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return frameError(offset, StageHeader, 0, err)
+	}
+	limit := ReadDelimitedOptions{MaxSize: r.opts.MaxBlockSize}.maxSize()
+	if size > uint64(limit) {
+		return frameError(offset, StageSize, size, &SizeTooLargeError{Size: size, MaxSize: limit})
+	}
+	// The stored data carries at most one codec byte of overhead over the
+	// uncompressed data, which bounds the allocation a corrupt header causes.
+	storedOpts := ReadDelimitedOptions{MaxSize: int64(size) + 1}
+	stored, err := storedOpts.readFrame(&r.cr, r.s.stored, offset)
+	if cap(stored) > cap(r.s.stored) {
+		r.s.stored = stored[:0]
+	}
+	if err == io.EOF {
+		err = frameError(offset, StageHeader, 0, io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return err
+	}
+	data, err := decompressPayload(r.s.plain[:0], stored, int(size))
+	if cap(data) > cap(r.s.plain) {
+		r.s.plain = data[:0]
+	}
+	if err == nil && uint64(len(data)) != size {
+		err = fmt.Errorf("pbutil: block holds %d bytes, header declares %d", len(data), size)
+	}
+	if err != nil {
+		return frameError(offset, StageDecompress, size, err)
+	}
+	stored32 := binary.LittleEndian.Uint32(trailer[:])
+	if computed := crc32.Checksum(data, castagnoli); stored32 != computed {
+		return frameError(offset, StageChecksum, size, &ChecksumError{Stored: stored32, Computed: computed})
+	}
+	r.block = Block{Offset: offset, Records: int(count), Data: data}
+	return nil
+}
+
+// Next decodes the next record of the container into m, advancing to the
+// following block as needed.  It returns io.EOF, unwrapped, once the container
+// ends cleanly.  Record errors report offsets relative to the start of the
+// block's uncompressed data.
+func (r *BlockReader) Next(m proto.Message) error {
+	for {
+		if r.active {
+			err := r.inner.Next(m)
+			if err != io.EOF {
+				return err
+			}
+			if got, want := r.inner.Index(), int64(r.block.Records); got != want {
+				return frameError(r.block.Offset, StagePayload, uint64(len(r.block.Data)),
+					fmt.Errorf("pbutil: block holds %d records, header declares %d", got, want))
+			}
+		}
+		if _, err := r.NextBlock(); err != nil {
+			return err
+		}
+		r.br.Reset(r.block.Data)
+		if r.inner == nil {
+			r.inner = NewReader(&r.br)
+		}
+		r.inner.Reset(&r.br)
+		r.active = true
+	}
+}
+
+// Offset returns the number of bytes of the container consumed so far.
+func (r *BlockReader) Offset() int64 { return r.offset }
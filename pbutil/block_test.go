@@ -0,0 +1,179 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// writeBlocks writes n small records to a block container with opts and
+// returns the container alongside the records.
+func writeBlocks(t *testing.T, opts BlockOptions, n int) ([]byte, []*testdata.Record) {
+	t.Helper()
+	return writeFixture(t, n, opts.NewBlockWriter, func(i int) *testdata.Record {
+		return &testdata.Record{First: proto.Uint64(uint64(i)), Third: proto.String("sensor")}
+	})
+}
+
+func TestBlockEndToEnd(t *testing.T) {
+	for _, codec := range []Codec{CodecNone, CodecGzip, CodecFlate} {
+		t.Run(codec.String(), func(t *testing.T) {
+			container, data := writeBlocks(t, BlockOptions{BlockSize: 256, Codec: codec}, 100)
+			r := NewBlockReader(bytes.NewReader(container))
+			for i, want := range data {
+				var got testdata.Record
+				if err := r.Next(&got); err != nil {
+					t.Fatalf("r.Next(&msg) for record %d = %v, want nil", i, err)
+				}
+				if !cmp.Equal(&got, want, protocmp.Transform()) {
+					t.Errorf("r.Next(&msg) for record %d; msg = %v, want %v", i, &got, want)
+				}
+			}
+			if got, want := r.Next(new(testdata.Record)), io.EOF; got != want {
+				t.Errorf("r.Next(&msg) at end = %v, want %v", got, want)
+			}
+			if got, want := r.Offset(), int64(len(container)); got != want {
+				t.Errorf("r.Offset() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestBlockCompresses(t *testing.T) {
+	container, data := writeBlocks(t, BlockOptions{Codec: CodecFlate}, 1000)
+	var plain bytes.Buffer
+	for _, msg := range data {
+		if _, err := WriteDelimited(&plain, msg); err != nil {
+			t.Fatalf("WriteDelimited(buf, %v) = ?, %v; want ?, nil", msg, err)
+		}
+	}
+	if len(container) >= plain.Len()/2 {
+		t.Errorf("block container is %d bytes, want well under the %d bytes of the plain stream", len(container), plain.Len())
+	}
+}
+
+func TestBlockNextBlock(t *testing.T) {
+	container, data := writeBlocks(t, BlockOptions{BlockSize: 256, Codec: CodecFlate}, 100)
+	r := NewBlockReader(bytes.NewReader(container))
+	var records int
+	var offsets []int64
+	for {
+		b, err := r.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("r.NextBlock() = ?, %v; want ?, nil", err)
+		}
+		offsets = append(offsets, b.Offset)
+		br := b.Reader()
+		for {
+			err := br.Next(new(testdata.Record))
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("reading block at %d: %v", b.Offset, err)
+			}
+		}
+		if got, want := br.Index(), int64(b.Records); got != want {
+			t.Errorf("block at %d holds %d records, header declares %d", b.Offset, got, want)
+		}
+		records += b.Records
+	}
+	if got, want := records, len(data); got != want {
+		t.Errorf("blocks hold %d records in total, want %d", got, want)
+	}
+	if len(offsets) < 2 || offsets[0] != 0 {
+		t.Errorf("block offsets = %v, want several beginning at 0", offsets)
+	}
+}
+
+func TestBlockNextBlockSkipsRemainder(t *testing.T) {
+	container, _ := writeBlocks(t, BlockOptions{BlockSize: 64}, 20)
+	r := NewBlockReader(bytes.NewReader(container))
+	var msg testdata.Record
+	if err := r.Next(&msg); err != nil {
+		t.Fatalf("r.Next(&msg) = %v, want nil", err)
+	}
+	first, err := r.NextBlock()
+	if err != nil {
+		t.Fatalf("r.NextBlock() = ?, %v; want ?, nil", err)
+	}
+	want := first.Records
+	if err := r.Next(&msg); err != nil {
+		t.Fatalf("r.Next(&msg) = %v, want nil", err)
+	}
+	if got := msg.GetFirst(); got <= uint64(want) {
+		t.Errorf("after NextBlock, r.Next(&msg) returned record %d, want one from a later block", got)
+	}
+}
+
+func TestBlockCorruption(t *testing.T) {
+	container, _ := writeBlocks(t, BlockOptions{Codec: CodecNone}, 10)
+	for _, test := range []struct {
+		name  string
+		in    []byte
+		stage Stage
+	}{
+		{
+			name:  "flipped data byte",
+			in:    flip(container, len(container)-2),
+			stage: StageChecksum,
+		},
+		{
+			name:  "truncated",
+			in:    container[:len(container)-1],
+			stage: StagePayload,
+		},
+		{
+			name:  "truncated header",
+			in:    container[:3],
+			stage: StageHeader,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewBlockReader(bytes.NewReader(test.in)).NextBlock()
+			var frameErr *FrameError
+			if !errors.As(err, &frameErr) || frameErr.Stage != test.stage {
+				t.Errorf("r.NextBlock() = ?, %v; want ?, *FrameError at %v", err, test.stage)
+			}
+		})
+	}
+}
+
+func TestBlockMaxBlockSize(t *testing.T) {
+	container, _ := writeBlocks(t, BlockOptions{Codec: CodecFlate}, 100)
+	_, err := BlockOptions{MaxBlockSize: 16}.NewBlockReader(bytes.NewReader(container)).NextBlock()
+	var tooLarge *SizeTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("r.NextBlock() = ?, %v; want ?, *SizeTooLargeError", err)
+	}
+}
+
+// flip returns a copy of b with the byte at i inverted.
+func flip(b []byte, i int) []byte {
+	out := append([]byte(nil), b...)
+	out[i] ^= 0xff
+	return out
+}
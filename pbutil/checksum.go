@@ -0,0 +1,63 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// checksumLen is the size of the CRC-32C trailer that follows each payload
+// when checksums are enabled.
+const checksumLen = 4
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ChecksumError is returned when a record's payload does not match the
+// CRC-32C checksum stored alongside it.
+type ChecksumError struct {
+	// Stored is the checksum recorded in the stream.
+	Stored uint32
+	// Computed is the checksum of the payload as read.
+	Computed uint32
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("pbutil: checksum mismatch: stored %08x, computed %08x", e.Stored, e.Computed)
+}
+
+// appendChecksum appends the CRC-32C trailer for payload to dst.
+func appendChecksum(dst, payload []byte) []byte {
+	return binary.LittleEndian.AppendUint32(dst, crc32.Checksum(payload, castagnoli))
+}
+
+// verifyChecksum reads the CRC-32C trailer following payload from r and
+// compares it against the payload.
+func verifyChecksum(r io.Reader, payload []byte) error {
+	var trailer [checksumLen]byte
+	if _, err := io.ReadFull(r, trailer[:]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	stored := binary.LittleEndian.Uint32(trailer[:])
+	if computed := crc32.Checksum(payload, castagnoli); stored != computed {
+		return &ChecksumError{Stored: stored, Computed: computed}
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestChecksumEndToEnd(t *testing.T) {
+	msg := &testdata.Record{First: proto.Uint64(1)}
+	var buf bytes.Buffer
+	n, err := WriteDelimitedOptions{Checksum: true}.WriteDelimited(&buf, msg)
+	if err != nil {
+		t.Fatalf("WriteDelimited(buf, %v) = ?, %v; want ?, nil", msg, err)
+	}
+	// The CRC-32C of {8, 1} is 0x9e1e3769.
+	if got, want := buf.Bytes(), []byte{2, 8, 1, 0x69, 0x37, 0x1e, 0x9e}; !cmp.Equal(got, want) {
+		t.Errorf("WriteDelimited(buf, %v) wrote %v, want %v", msg, got, want)
+	}
+	if got, want := n, buf.Len(); got != want {
+		t.Errorf("WriteDelimited(buf, %v) = %d, ?; want %d, ?", msg, got, want)
+	}
+
+	var out testdata.Record
+	read, err := ReadDelimitedOptions{Checksum: true}.ReadDelimited(&buf, &out)
+	if read != n || err != nil {
+		t.Errorf("ReadDelimited(buf, &out) = %v, %v; want %v, nil", read, err, n)
+	}
+	if !cmp.Equal(&out, msg, protocmp.Transform()) {
+		t.Errorf("ReadDelimited(buf, &out); out = %v, want %v", &out, msg)
+	}
+}
+
+func TestChecksumErrors(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		in       []byte
+		cause    error
+		mismatch bool
+	}{
+		{
+			name:     "corrupt payload",
+			in:       []byte{2, 8, 2, 0x69, 0x37, 0x1e, 0x9e},
+			mismatch: true,
+		},
+		{
+			name:     "corrupt checksum",
+			in:       []byte{2, 8, 1, 0x69, 0x37, 0x1e, 0x9f},
+			mismatch: true,
+		},
+		{
+			name:  "truncated checksum",
+			in:    []byte{2, 8, 1, 0x69, 0x37},
+			cause: io.ErrUnexpectedEOF,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := ReadDelimitedOptions{Checksum: true}.ReadDelimited(bytes.NewReader(test.in), new(testdata.Record))
+			if got, want := n, len(test.in); got != want {
+				t.Errorf("ReadDelimited(%v, &msg) = %v, ?; want %v, ?", test.in, got, want)
+			}
+			var frameErr *FrameError
+			if !errors.As(err, &frameErr) || frameErr.Stage != StageChecksum {
+				t.Fatalf("ReadDelimited(%v, &msg) = ?, %v; want ?, *FrameError at StageChecksum", test.in, err)
+			}
+			var mismatch *ChecksumError
+			if got, want := errors.As(err, &mismatch), test.mismatch; got != want {
+				t.Errorf("errors.As(%v, *ChecksumError) = %v, want %v", err, got, want)
+			}
+			if test.cause != nil && !errors.Is(err, test.cause) {
+				t.Errorf("ReadDelimited(%v, &msg) = ?, %v; want ?, %v", test.in, err, test.cause)
+			}
+		})
+	}
+}
+
+func TestChecksumReaderWriter(t *testing.T) {
+	data := []*testdata.Record{
+		{First: proto.Uint64(1)},
+		{},
+		{Third: proto.String("third")},
+	}
+	var buf bytes.Buffer
+	w := WriteDelimitedOptions{Checksum: true}.NewWriter(&buf)
+	for i, msg := range data {
+		if err := w.Write(msg); err != nil {
+			t.Fatalf("w.Write(data[%d]) = %v, want nil", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+	if got, want := w.Offset(), int64(buf.Len()); got != want {
+		t.Errorf("w.Offset() = %d, want %d", got, want)
+	}
+
+	r := ReadDelimitedOptions{Checksum: true}.NewReader(&buf)
+	if err := r.Skip(); err != nil {
+		t.Fatalf("r.Skip() = %v, want nil", err)
+	}
+	for i, want := range data[1:] {
+		var got testdata.Record
+		if err := r.Next(&got); err != nil {
+			t.Fatalf("r.Next(&msg) for record %d = %v, want nil", i+1, err)
+		}
+		if !cmp.Equal(&got, want, protocmp.Transform()) {
+			t.Errorf("r.Next(&msg) for record %d; msg = %v, want %v", i+1, &got, want)
+		}
+	}
+	if got, want := r.Next(new(testdata.Record)), io.EOF; got != want {
+		t.Errorf("r.Next(&msg) at end = %v, want %v", got, want)
+	}
+}
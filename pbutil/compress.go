@@ -0,0 +1,302 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Codec identifies the compression applied to a record's payload in a stream
+// written with WriteDelimitedOptions.Compressed.  It is stored as the first
+// byte of each payload.
+type Codec byte
+
+const (
+	// CodecNone stores the payload uncompressed.
+	CodecNone Codec = iota
+	// CodecGzip compresses the payload with gzip (RFC 1952).
+	CodecGzip
+	// CodecFlate compresses the payload with raw DEFLATE (RFC 1951), which
+	// avoids gzip's header and trailer overhead on small records.
+	CodecFlate
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecNone:
+		return "none"
+	case CodecGzip:
+		return "gzip"
+	case CodecFlate:
+		return "flate"
+	default:
+		return fmt.Sprintf("Codec(%d)", byte(c))
+	}
+}
+
+// Compressor implements a Codec.
+type Compressor interface {
+	// Compress appends the compressed form of src to dst.
+	Compress(dst, src []byte) ([]byte, error)
+	// Decompress appends the decompressed form of src to dst.  It must fail
+	// rather than produce more than max bytes.
+	Decompress(dst, src []byte, max int) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[Codec]Compressor{
+		CodecNone:  noneCompressor{},
+		CodecGzip:  gzipCompressor{},
+		CodecFlate: flateCompressor{},
+	}
+)
+
+// RegisterCodec makes an additional compression codec, such as Zstandard or
+// Snappy, available to readers and writers of compressed streams.  It panics
+// if c is already registered.  Applications that produce streams with a
+// custom codec must ensure that every consumer registers it with the same
+// value.
+func RegisterCodec(c Codec, comp Compressor) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if _, ok := codecs[c]; ok {
+		panic(fmt.Sprintf("pbutil: codec %v already registered", c))
+	}
+	codecs[c] = comp
+}
+
+// lookupCodec returns the Compressor registered for c.
+func lookupCodec(c Codec) (Compressor, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	comp, ok := codecs[c]
+	if !ok {
+		return nil, fmt.Errorf("pbutil: unknown codec %v", c)
+	}
+	return comp, nil
+}
+
+// compressPayload appends to dst the stored form of payload under codec c:
+// the codec byte followed by the compressed payload.  If compression would
+// make the payload larger, it is stored under CodecNone instead.
+func compressPayload(dst, payload []byte, c Codec) ([]byte, error) {
+	start := len(dst)
+	if c != CodecNone {
+		comp, err := lookupCodec(c)
+		if err != nil {
+			return dst, err
+		}
+		out, err := comp.Compress(append(dst, byte(c)), payload)
+		if err != nil {
+			return dst, err
+		}
+		if len(out)-start-1 <= len(payload) {
+			return out, nil
+		}
+		dst = out[:start]
+	}
+	return append(append(dst, byte(CodecNone)), payload...), nil
+}
+
+// decompressPayload appends to dst the message bytes held in stored, which
+// must begin with a codec byte.  The result may not exceed max bytes.
+func decompressPayload(dst, stored []byte, max int) ([]byte, error) {
+	if len(stored) == 0 {
+		return dst, io.ErrUnexpectedEOF
+	}
+	comp, err := lookupCodec(Codec(stored[0]))
+	if err != nil {
+		return dst, err
+	}
+	return comp.Decompress(dst, stored[1:], max)
+}
+
+// errDecompressedTooLarge is returned when a payload inflates beyond the
+// permitted record size.
+type errDecompressedTooLarge int
+
+func (e errDecompressedTooLarge) Error() string {
+	return fmt.Sprintf("pbutil: decompressed payload exceeds %d bytes", int(e))
+}
+
+// readAllMax appends everything from r to dst, failing if r yields more than
+// max bytes.  It grows dst in place, so a dst with room to spare costs no
+// allocation.
+func readAllMax(dst []byte, r io.Reader, max int) ([]byte, error) {
+	start := len(dst)
+	for {
+		if len(dst) == cap(dst) {
+			dst = append(dst, 0)[:len(dst)]
+		}
+		// Reading at most one byte past max suffices to detect an excess.
+		end := cap(dst)
+		if limit := start + max + 1; limit > start && end > limit {
+			end = limit
+		}
+		n, err := r.Read(dst[len(dst):end])
+		dst = dst[:len(dst)+n]
+		if len(dst)-start > max {
+			return dst[:start], errDecompressedTooLarge(max)
+		}
+		if err == io.EOF {
+			return dst, nil
+		}
+		if err != nil {
+			return dst[:start], err
+		}
+	}
+}
+
+// appendWriter is an io.Writer that appends to b.
+type appendWriter struct {
+	b []byte
+}
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	w.b = append(w.b, p...)
+	return len(p), nil
+}
+
+type noneCompressor struct{}
+
+func (noneCompressor) Compress(dst, src []byte) ([]byte, error) {
+	return append(dst, src...), nil
+}
+
+func (noneCompressor) Decompress(dst, src []byte, max int) ([]byte, error) {
+	if len(src) > max {
+		return dst, errDecompressedTooLarge(max)
+	}
+	return append(dst, src...), nil
+}
+
+// The gzip and flate compressors keep their writers and readers in pools,
+// since each holds hundreds of kilobytes of state that would otherwise be
+// allocated anew for every record.
+
+// gzipEncoder is a pooled gzip.Writer along with its destination.
+type gzipEncoder struct {
+	out appendWriter
+	zw  *gzip.Writer
+}
+
+var gzipEncoders = sync.Pool{
+	New: func() any {
+		e := new(gzipEncoder)
+		e.zw = gzip.NewWriter(&e.out)
+		return e
+	},
+}
+
+// gzipDecoder is a pooled gzip.Reader along with its source.
+type gzipDecoder struct {
+	in bytes.Reader
+	zr gzip.Reader
+}
+
+var gzipDecoders = sync.Pool{
+	New: func() any { return new(gzipDecoder) },
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Compress(dst, src []byte) ([]byte, error) {
+	e := gzipEncoders.Get().(*gzipEncoder)
+	defer gzipEncoders.Put(e)
+	e.out.b = dst
+	defer func() { e.out.b = nil }()
+	e.zw.Reset(&e.out)
+	if _, err := e.zw.Write(src); err != nil {
+		return dst, err
+	}
+	if err := e.zw.Close(); err != nil {
+		return dst, err
+	}
+	return e.out.b, nil
+}
+
+func (gzipCompressor) Decompress(dst, src []byte, max int) ([]byte, error) {
+	d := gzipDecoders.Get().(*gzipDecoder)
+	defer gzipDecoders.Put(d)
+	d.in.Reset(src)
+	defer d.in.Reset(nil)
+	if err := d.zr.Reset(&d.in); err != nil {
+		return dst, err
+	}
+	return readAllMax(dst, &d.zr, max)
+}
+
+// flateEncoder is a pooled flate.Writer along with its destination.
+type flateEncoder struct {
+	out appendWriter
+	zw  *flate.Writer
+}
+
+var flateEncoders = sync.Pool{
+	New: func() any {
+		e := new(flateEncoder)
+		// NewWriter only fails for an invalid compression level.
+		e.zw, _ = flate.NewWriter(&e.out, flate.DefaultCompression)
+		return e
+	},
+}
+
+// flateDecoder is a pooled flate reader along with its source.
+type flateDecoder struct {
+	in bytes.Reader
+	zr io.ReadCloser
+}
+
+var flateDecoders = sync.Pool{
+	New: func() any {
+		d := new(flateDecoder)
+		d.zr = flate.NewReader(&d.in)
+		return d
+	},
+}
+
+type flateCompressor struct{}
+
+func (flateCompressor) Compress(dst, src []byte) ([]byte, error) {
+	e := flateEncoders.Get().(*flateEncoder)
+	defer flateEncoders.Put(e)
+	e.out.b = dst
+	defer func() { e.out.b = nil }()
+	e.zw.Reset(&e.out)
+	if _, err := e.zw.Write(src); err != nil {
+		return dst, err
+	}
+	if err := e.zw.Close(); err != nil {
+		return dst, err
+	}
+	return e.out.b, nil
+}
+
+func (flateCompressor) Decompress(dst, src []byte, max int) ([]byte, error) {
+	d := flateDecoders.Get().(*flateDecoder)
+	defer flateDecoders.Put(d)
+	d.in.Reset(src)
+	defer d.in.Reset(nil)
+	if err := d.zr.(flate.Resetter).Reset(&d.in, nil); err != nil {
+		return dst, err
+	}
+	return readAllMax(dst, d.zr, max)
+}
@@ -0,0 +1,282 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// reverseCompressor is a toy codec that stores payloads reversed.
+type reverseCompressor struct{}
+
+func (reverseCompressor) Compress(dst, src []byte) ([]byte, error) {
+	for i := len(src) - 1; i >= 0; i-- {
+		dst = append(dst, src[i])
+	}
+	return dst, nil
+}
+
+func (c reverseCompressor) Decompress(dst, src []byte, max int) ([]byte, error) {
+	if len(src) > max {
+		return dst, errDecompressedTooLarge(max)
+	}
+	return c.Compress(dst, src)
+}
+
+const codecReverse Codec = 200
+
+func init() {
+	RegisterCodec(codecReverse, reverseCompressor{})
+}
+
+func TestCompressedMixedCodecs(t *testing.T) {
+	compressible := &testdata.Record{Third: proto.String(strings.Repeat("telemetry ", 50))}
+	for _, test := range []struct {
+		name      string
+		codec     Codec
+		msg       *testdata.Record
+		wantCodec Codec
+	}{
+		{
+			name:      "none",
+			codec:     CodecNone,
+			msg:       compressible,
+			wantCodec: CodecNone,
+		},
+		{
+			name:      "gzip",
+			codec:     CodecGzip,
+			msg:       compressible,
+			wantCodec: CodecGzip,
+		},
+		{
+			name:      "flate",
+			codec:     CodecFlate,
+			msg:       compressible,
+			wantCodec: CodecFlate,
+		},
+		{
+			name:      "incompressible falls back to none",
+			codec:     CodecGzip,
+			msg:       &testdata.Record{First: proto.Uint64(1)},
+			wantCodec: CodecNone,
+		},
+		{
+			name:      "registered codec",
+			codec:     codecReverse,
+			msg:       &testdata.Record{First: proto.Uint64(1)},
+			wantCodec: codecReverse,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			opts := WriteDelimitedOptions{Compressed: true, Codec: test.codec}
+			if _, err := opts.WriteDelimited(&buf, test.msg); err != nil {
+				t.Fatalf("WriteDelimited(buf, %v) = ?, %v; want ?, nil", test.msg, err)
+			}
+			stored, _, err := ReadDelimitedBytes(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("ReadDelimitedBytes(buf) = ?, ?, %v; want ?, ?, nil", err)
+			}
+			if got, want := Codec(stored[0]), test.wantCodec; got != want {
+				t.Errorf("record stored with codec %v, want %v", got, want)
+			}
+			var out testdata.Record
+			if _, err := (ReadDelimitedOptions{Compressed: true}).ReadDelimited(&buf, &out); err != nil {
+				t.Fatalf("ReadDelimited(buf, &out) = ?, %v; want ?, nil", err)
+			}
+			if !cmp.Equal(&out, test.msg, protocmp.Transform()) {
+				t.Errorf("ReadDelimited(buf, &out); out = %v, want %v", &out, test.msg)
+			}
+		})
+	}
+}
+
+func TestCompressedReaderWriterMixedStream(t *testing.T) {
+	long := strings.Repeat("abcdefgh", 64)
+	data := []*testdata.Record{
+		{Third: proto.String(long)},
+		{First: proto.Uint64(1)},
+		{Third: proto.String(long + "x")},
+	}
+	codecs := []Codec{CodecGzip, CodecNone, CodecFlate}
+	var buf bytes.Buffer
+	w := WriteDelimitedOptions{Compressed: true, Checksum: true}.NewWriter(&buf)
+	for i, msg := range data {
+		w.SetCodec(codecs[i])
+		if err := w.Write(msg); err != nil {
+			t.Fatalf("w.Write(data[%d]) = %v, want nil", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+
+	r := ReadDelimitedOptions{Compressed: true, Checksum: true}.NewReader(&buf)
+	for i, want := range data {
+		var got testdata.Record
+		if err := r.Next(&got); err != nil {
+			t.Fatalf("r.Next(&msg) for record %d = %v, want nil", i, err)
+		}
+		if !cmp.Equal(&got, want, protocmp.Transform()) {
+			t.Errorf("r.Next(&msg) for record %d; msg = %v, want %v", i, &got, want)
+		}
+	}
+	if got, want := r.Next(new(testdata.Record)), io.EOF; got != want {
+		t.Errorf("r.Next(&msg) at end = %v, want %v", got, want)
+	}
+}
+
+func TestCompressedErrors(t *testing.T) {
+	var bomb bytes.Buffer
+	msg := &testdata.Record{Third: proto.String(strings.Repeat("z", 4096))}
+	if _, err := (WriteDelimitedOptions{Compressed: true, Codec: CodecFlate}).WriteDelimited(&bomb, msg); err != nil {
+		t.Fatalf("WriteDelimited(buf, %v) = ?, %v; want ?, nil", msg, err)
+	}
+
+	for _, test := range []struct {
+		name string
+		opts ReadDelimitedOptions
+		in   []byte
+	}{
+		{
+			name: "unknown codec",
+			opts: ReadDelimitedOptions{Compressed: true},
+			in:   []byte{3, 99, 8, 1},
+		},
+		{
+			name: "missing codec byte",
+			opts: ReadDelimitedOptions{Compressed: true},
+			in:   []byte{0},
+		},
+		{
+			name: "corrupt gzip",
+			opts: ReadDelimitedOptions{Compressed: true},
+			in:   []byte{3, byte(CodecGzip), 8, 1},
+		},
+		{
+			name: "exceeds MaxSize once decompressed",
+			opts: ReadDelimitedOptions{Compressed: true, MaxSize: 1024},
+			in:   bomb.Bytes(),
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.opts.ReadDelimited(bytes.NewReader(test.in), new(testdata.Record))
+			var frameErr *FrameError
+			if !errors.As(err, &frameErr) || frameErr.Stage != StageDecompress {
+				t.Errorf("ReadDelimited(%v, &msg) = ?, %v; want ?, *FrameError at StageDecompress", test.in, err)
+			}
+		})
+	}
+}
+
+func TestRegisterCodecDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("RegisterCodec(CodecGzip, ...) did not panic")
+		}
+	}()
+	RegisterCodec(CodecGzip, reverseCompressor{})
+}
+
+// allocatedPerCall reports the average number of bytes f allocates per call
+// over n calls.
+func allocatedPerCall(n int, f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	for i := 0; i < n; i++ {
+		f()
+	}
+	runtime.ReadMemStats(&after)
+	return (after.TotalAlloc - before.TotalAlloc) / uint64(n)
+}
+
+func TestCompressedRecordsReuseCodecState(t *testing.T) {
+	// A small record, though one large enough for flate to compress.
+	msg := &testdata.Record{First: proto.Uint64(1), Third: proto.String(strings.Repeat("sensor", 30))}
+	for _, codec := range []Codec{CodecGzip, CodecFlate} {
+		t.Run(codec.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			w := WriteDelimitedOptions{Compressed: true, Codec: codec}.NewWriter(&buf)
+			write := func() {
+				if err := w.Write(msg); err != nil {
+					t.Fatalf("w.Write(msg) = %v, want nil", err)
+				}
+			}
+			// Warm the Writer's buffers and the codec's pool.
+			for i := 0; i < 10; i++ {
+				write()
+			}
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			if payload, _, err := ReadDelimitedBytes(bytes.NewReader(buf.Bytes())); err != nil || Codec(payload[0]) != codec {
+				t.Fatalf("record stored as %v, %v; want codec %v", payload, err, codec)
+			}
+			// A freshly allocated compressor costs about a megabyte and a
+			// decompressor tens of kilobytes.  The limits leave room for
+			// sync.Pool dropping entries, as it deliberately does for a
+			// quarter of them under the race detector.
+			if got, limit := allocatedPerCall(200, write), uint64(512<<10); got > limit {
+				t.Errorf("w.Write(msg) allocated %d bytes per record, want at most %d", got, limit)
+			}
+
+			if err := w.Flush(); err != nil {
+				t.Fatal(err)
+			}
+			stream := buf.Bytes()
+			r := ReadDelimitedOptions{Compressed: true}.NewReader(bytes.NewReader(stream))
+			var got testdata.Record
+			read := func() {
+				if err := r.Next(&got); err == io.EOF {
+					r.Reset(bytes.NewReader(stream))
+				} else if err != nil {
+					t.Fatalf("r.Next(&msg) = %v, want nil", err)
+				}
+			}
+			for i := 0; i < 10; i++ {
+				read()
+			}
+			if got, limit := allocatedPerCall(200, read), uint64(16<<10); got > limit {
+				t.Errorf("r.Next(&msg) allocated %d bytes per record, want at most %d", got, limit)
+			}
+		})
+	}
+}
+
+func BenchmarkCompressedWrite(b *testing.B) {
+	msg := &testdata.Record{First: proto.Uint64(1), Third: proto.String(strings.Repeat("sensor", 30))}
+	for _, codec := range []Codec{CodecGzip, CodecFlate} {
+		b.Run(codec.String(), func(b *testing.B) {
+			w := WriteDelimitedOptions{Compressed: true, Codec: codec}.NewWriter(io.Discard)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := w.Write(msg); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
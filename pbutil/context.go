@@ -0,0 +1,271 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Cancellation
+//
+// The context-aware functions and methods check their context before every
+// read from or write to the underlying stream, so they stop promptly between
+// the underlying operations once the context ends.  An operation that is
+// already blocked can only be interrupted if the stream supports deadlines,
+// as net.Conn and *os.File do through SetReadDeadline and SetWriteDeadline.
+// For such streams, the context's deadline is applied for the duration of the
+// call, cancellation forces the deadline into the past, and the deadline is
+// cleared before returning, replacing any the caller had set.  Buffered
+// wrappers, such as *bufio.Reader, hide these methods.
+//
+// An error caused by the context ending matches ctx.Err() under errors.Is.  If
+// the context ends partway through a record, the stream is no longer
+// positioned on a record boundary and should be abandoned.
+
+// aLongTimeAgo is a deadline in the past, which interrupts blocked operations.
+var aLongTimeAgo = time.Unix(1, 0)
+
+type readDeadliner interface {
+	SetReadDeadline(t time.Time) error
+}
+
+type writeDeadliner interface {
+	SetWriteDeadline(t time.Time) error
+}
+
+// ctxReader checks ctx before every read from r.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *ctxReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.r.Read(p)
+	return n, contextError(c.ctx, err)
+}
+
+// ctxWriter checks ctx, if set, before every write to w.
+type ctxWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (c *ctxWriter) Write(p []byte) (int, error) {
+	if c.ctx == nil {
+		return c.w.Write(p)
+	}
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := c.w.Write(p)
+	return n, contextError(c.ctx, err)
+}
+
+// contextError attributes err to ctx if a deadline that ctx imposed caused it.
+func contextError(ctx context.Context, err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+	if cerr := ctx.Err(); cerr != nil {
+		return cerr
+	}
+	if _, ok := ctx.Deadline(); ok && errors.Is(err, os.ErrDeadlineExceeded) {
+		// The stream's deadline may fire fractionally before ctx's own timer.
+		return context.DeadlineExceeded
+	}
+	return err
+}
+
+// watchContext applies ctx's deadline through set and forces the deadline into
+// the past should ctx be cancelled.  The returned function undoes this and must
+// be called once the operation completes.
+func watchContext(ctx context.Context, set func(time.Time) error) (stop func()) {
+	deadline, hasDeadline := ctx.Deadline()
+	done := ctx.Done()
+	if !hasDeadline && done == nil {
+		return func() {}
+	}
+	if hasDeadline {
+		set(deadline)
+	}
+	if done == nil {
+		return func() { set(time.Time{}) }
+	}
+	stopc := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-done:
+			set(aLongTimeAgo)
+		case <-stopc:
+		}
+	}()
+	return func() {
+		close(stopc)
+		<-exited
+		set(time.Time{})
+	}
+}
+
+// watchReader calls watchContext if r supports read deadlines.
+func watchReader(ctx context.Context, r io.Reader) (stop func()) {
+	if d, ok := r.(readDeadliner); ok {
+		return watchContext(ctx, d.SetReadDeadline)
+	}
+	return func() {}
+}
+
+// watchWriter calls watchContext if w supports write deadlines.
+func watchWriter(ctx context.Context, w io.Writer) (stop func()) {
+	if d, ok := w.(writeDeadliner); ok {
+		return watchContext(ctx, d.SetWriteDeadline)
+	}
+	return func() {}
+}
+
+// ReadDelimitedContext behaves like ReadDelimited but abandons the read once
+// ctx ends.  The byte count is that of ReadDelimited: the number of bytes
+// consumed from r, including those of a record left incomplete.
+func ReadDelimitedContext(ctx context.Context, r io.Reader, m proto.Message) (n int, err error) {
+	return ReadDelimitedOptions{}.ReadDelimitedContext(ctx, r, m)
+}
+
+// ReadDelimitedContext behaves like the package-level ReadDelimitedContext
+// function but honors the options in o.
+func (o ReadDelimitedOptions) ReadDelimitedContext(ctx context.Context, r io.Reader, m proto.Message) (n int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	defer watchReader(ctx, r)()
+	return o.ReadDelimited(&ctxReader{ctx: ctx, r: r}, m)
+}
+
+// WriteDelimitedContext behaves like WriteDelimited but abandons the write
+// once ctx ends.  The byte count is that of WriteDelimited: the number of bytes
+// written to w, including those of a record left incomplete.
+func WriteDelimitedContext(ctx context.Context, w io.Writer, m proto.Message) (n int, err error) {
+	return WriteDelimitedOptions{}.WriteDelimitedContext(ctx, w, m)
+}
+
+// WriteDelimitedContext behaves like the package-level WriteDelimitedContext
+// function but honors the options in o.
+func (o WriteDelimitedOptions) WriteDelimitedContext(ctx context.Context, w io.Writer, m proto.Message) (n int, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	payload, err := proto.Marshal(m)
+	if err != nil {
+		return 0, err
+	}
+	defer watchWriter(ctx, w)()
+	return o.WriteDelimitedBytes(&ctxWriter{ctx: ctx, w: w}, payload)
+}
+
+// bindContext routes the Reader's reads through ctx until the returned
+// function is called.
+func (r *Reader) bindContext(ctx context.Context) (unbind func()) {
+	src := &r.cr.r
+	if r.resync != nil {
+		src = &r.resync.src.r
+	}
+	orig := *src
+	stop := watchReader(ctx, orig)
+	*src = &ctxReader{ctx: ctx, r: orig}
+	return func() {
+		*src = orig
+		stop()
+	}
+}
+
+// NextContext behaves like Next but abandons the read once ctx ends.
+func (r *Reader) NextContext(ctx context.Context, m proto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer r.bindContext(ctx)()
+	return r.Next(m)
+}
+
+// NextBytesContext behaves like NextBytes but abandons the read once ctx
+// ends.
+func (r *Reader) NextBytesContext(ctx context.Context) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer r.bindContext(ctx)()
+	return r.NextBytes()
+}
+
+// NextContext behaves like Next but abandons the read once ctx ends.
+func (r *TaggedReader) NextContext(ctx context.Context) (proto.Message, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer r.r.bindContext(ctx)()
+	return r.Next()
+}
+
+// NextContext behaves like Next but abandons the read once ctx ends.
+func (r *BlockReader) NextContext(ctx context.Context, m proto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	orig := r.cr.r
+	defer watchReader(ctx, orig)()
+	r.cr.r = &ctxReader{ctx: ctx, r: orig}
+	defer func() { r.cr.r = orig }()
+	return r.Next(m)
+}
+
+// bindContext routes the Writer's writes through ctx until the returned
+// function is called.
+func (w *Writer) bindContext(ctx context.Context) (unbind func()) {
+	stop := watchWriter(ctx, w.cw.w)
+	w.cw.ctx = ctx
+	return func() {
+		w.cw.ctx = nil
+		stop()
+	}
+}
+
+// WriteContext behaves like Write but abandons any write to the underlying
+// io.Writer once ctx ends.  Since records are buffered, a cancelled write
+// leaves the Writer with a sticky error, as any other write failure does.
+func (w *Writer) WriteContext(ctx context.Context, m proto.Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer w.bindContext(ctx)()
+	return w.Write(m)
+}
+
+// FlushContext behaves like Flush but abandons the flush once ctx ends.
+func (w *Writer) FlushContext(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	defer w.bindContext(ctx)()
+	return w.Flush()
+}
@@ -0,0 +1,260 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// cancelAfter is an io.Reader that yields its data one byte at a time and
+// cancels a context once it has yielded n bytes.
+type cancelAfter struct {
+	data   []byte
+	n      int
+	cancel context.CancelFunc
+}
+
+func (c *cancelAfter) Read(p []byte) (int, error) {
+	if len(c.data) == 0 {
+		return 0, io.EOF
+	}
+	if c.n--; c.n == 0 {
+		c.cancel()
+	}
+	p[0], c.data = c.data[0], c.data[1:]
+	return 1, nil
+}
+
+func TestReadDelimitedContext(t *testing.T) {
+	want := &testdata.Record{First: proto.Uint64(1)}
+	var stream bytes.Buffer
+	if _, err := WriteDelimited(&stream, want); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("complete", func(t *testing.T) {
+		var got testdata.Record
+		n, err := ReadDelimitedContext(context.Background(), bytes.NewReader(stream.Bytes()), &got)
+		if n != stream.Len() || err != nil {
+			t.Fatalf("ReadDelimitedContext(ctx, stream, &msg) = %d, %v; want %d, nil", n, err, stream.Len())
+		}
+		if !cmp.Equal(&got, want, protocmp.Transform()) {
+			t.Errorf("ReadDelimitedContext(ctx, stream, &msg); msg = %v, want %v", &got, want)
+		}
+	})
+
+	t.Run("cancelled before reading", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		n, err := ReadDelimitedContext(ctx, bytes.NewReader(stream.Bytes()), new(testdata.Record))
+		if n != 0 || err != context.Canceled {
+			t.Errorf("ReadDelimitedContext(cancelled, stream, &msg) = %d, %v; want 0, %v", n, err, context.Canceled)
+		}
+	})
+
+	t.Run("cancelled partway", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		r := &cancelAfter{data: stream.Bytes(), n: 2, cancel: cancel}
+		n, err := ReadDelimitedContext(ctx, r, new(testdata.Record))
+		if n != 2 || !errors.Is(err, context.Canceled) {
+			t.Errorf("ReadDelimitedContext(ctx, stream, &msg) = %d, %v; want 2, %v", n, err, context.Canceled)
+		}
+		var fe *FrameError
+		if !errors.As(err, &fe) || fe.Stage != StagePayload {
+			t.Errorf("ReadDelimitedContext(ctx, stream, &msg) = ?, %v; want *FrameError in %v", err, StagePayload)
+		}
+	})
+}
+
+func TestReadDelimitedContextInterruptsBlockedRead(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+		want error
+	}{
+		{
+			name: "cancelled",
+			ctx: func() (context.Context, context.CancelFunc) {
+				ctx, cancel := context.WithCancel(context.Background())
+				time.AfterFunc(20*time.Millisecond, cancel)
+				return ctx, cancel
+			},
+			want: context.Canceled,
+		},
+		{
+			name: "deadline",
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 20*time.Millisecond)
+			},
+			want: context.DeadlineExceeded,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer client.Close()
+			defer server.Close()
+			ctx, cancel := test.ctx()
+			defer cancel()
+			// The server never writes, so the read blocks until interrupted.
+			_, err := ReadDelimitedContext(ctx, client, new(testdata.Record))
+			if !errors.Is(err, test.want) {
+				t.Fatalf("ReadDelimitedContext(ctx, conn, &msg) = ?, %v; want ?, %v", err, test.want)
+			}
+			// The deadline is cleared afterward, so the connection remains
+			// usable.
+			go WriteDelimited(server, &testdata.Record{First: proto.Uint64(2)})
+			var got testdata.Record
+			if _, err := ReadDelimited(client, &got); err != nil || got.GetFirst() != 2 {
+				t.Errorf("ReadDelimited(conn, &msg) after interruption = %v with msg %v, want nil with first: 2", err, &got)
+			}
+		})
+	}
+}
+
+func TestWriteDelimitedContext(t *testing.T) {
+	msg := &testdata.Record{First: proto.Uint64(1)}
+
+	var buf bytes.Buffer
+	n, err := WriteDelimitedContext(context.Background(), &buf, msg)
+	if n != buf.Len() || err != nil {
+		t.Fatalf("WriteDelimitedContext(ctx, &buf, msg) = %d, %v; want %d, nil", n, err, buf.Len())
+	}
+	var want bytes.Buffer
+	WriteDelimited(&want, msg)
+	if !bytes.Equal(buf.Bytes(), want.Bytes()) {
+		t.Errorf("WriteDelimitedContext(ctx, &buf, msg) wrote %v, want %v", buf.Bytes(), want.Bytes())
+	}
+
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	// Nobody reads from the server, so the write blocks until interrupted.
+	n, err = WriteDelimitedContext(ctx, client, msg)
+	if n != 0 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WriteDelimitedContext(ctx, conn, msg) = %d, %v; want 0, %v", n, err, context.DeadlineExceeded)
+	}
+}
+
+func TestReaderNextContext(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go WriteDelimited(server, &testdata.Record{First: proto.Uint64(1)})
+
+	r := NewReader(client)
+	var got testdata.Record
+	if err := r.NextContext(context.Background(), &got); err != nil || got.GetFirst() != 1 {
+		t.Fatalf("r.NextContext(ctx, &msg) = %v with msg %v, want nil with first: 1", err, &got)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+	if err := r.NextContext(ctx, &got); !errors.Is(err, context.Canceled) {
+		t.Errorf("r.NextContext(ctx, &msg) = %v, want %v", err, context.Canceled)
+	}
+	if _, err := r.NextBytesContext(ctx); err != context.Canceled {
+		t.Errorf("r.NextBytesContext(cancelled) = ?, %v; want ?, %v", err, context.Canceled)
+	}
+}
+
+func TestReaderNextContextPlainReader(t *testing.T) {
+	// io.Pipe lacks deadlines, so cancellation is only noticed between reads.
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		pw.Write([]byte{2})
+		time.Sleep(20 * time.Millisecond)
+		pw.Write([]byte{8})
+		pw.Write([]byte{1})
+		pw.Close()
+	}()
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewReader(pr)
+	var got testdata.Record
+	time.AfterFunc(5*time.Millisecond, cancel)
+	err := r.NextContext(ctx, &got)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("r.NextContext(ctx, &msg) = %v, want %v", err, context.Canceled)
+	}
+	if got, want := r.Offset(), int64(2); got != want {
+		t.Errorf("r.Offset() = %d, want %d", got, want)
+	}
+}
+
+func TestTaggedReaderNextContext(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteTagged(&buf, &testdata.Record{First: proto.Uint64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	r := NewTaggedReader(NewReader(&buf))
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := r.NextContext(ctx); err != nil {
+		t.Fatalf("r.NextContext(ctx) = ?, %v; want ?, nil", err)
+	}
+	cancel()
+	if _, err := r.NextContext(ctx); err != context.Canceled {
+		t.Errorf("r.NextContext(cancelled) = ?, %v; want ?, %v", err, context.Canceled)
+	}
+}
+
+func TestBlockReaderNextContext(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewBlockWriter(&buf)
+	if err := w.Write(&testdata.Record{First: proto.Uint64(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := NewBlockReader(&cancelAfter{data: buf.Bytes(), n: 3, cancel: cancel})
+	if err := r.NextContext(ctx, new(testdata.Record)); !errors.Is(err, context.Canceled) {
+		t.Errorf("r.NextContext(ctx, &msg) = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestWriterContext(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	w := NewWriterSize(client, 16)
+	msg := &testdata.Record{First: proto.Uint64(1)}
+	// The record fits in the buffer, so nothing reaches the connection.
+	if err := w.WriteContext(context.Background(), msg); err != nil {
+		t.Fatalf("w.WriteContext(ctx, msg) = %v, want nil", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.FlushContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("w.FlushContext(ctx) = %v, want %v", err, context.DeadlineExceeded)
+	}
+	cancel()
+	if err := w.WriteContext(ctx, msg); err != context.DeadlineExceeded {
+		t.Errorf("w.WriteContext(expired, msg) = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bufio"
+	"io"
+	"math/rand"
+	"sort"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// CopyOptions configures the functions that move records between streams
+// without decoding them.  Records are read according to Read and written
+// according to Write, so the framing may change along the way; for instance,
+// copying with Read.Compressed set and Write.Compressed unset decompresses
+// every record.
+type CopyOptions struct {
+	Read  ReadDelimitedOptions
+	Write WriteDelimitedOptions
+}
+
+// Copy copies up to n records from src to dst, or every remaining record if n
+// is negative.  It returns the number of records copied and the first error
+// encountered, if any; records copied before an error are still written.  src
+// is read no further than the records copied, so callers that do not need
+// that guarantee should supply a buffered reader for efficiency.
+func Copy(dst io.Writer, src io.Reader, n int64) (copied int64, err error) {
+	return CopyOptions{}.Copy(dst, src, n)
+}
+
+// Copy behaves like the package-level Copy function but frames records
+// according to o.
+func (o CopyOptions) Copy(dst io.Writer, src io.Reader, n int64) (copied int64, err error) {
+	w := o.Write.NewWriter(dst)
+	copied, err = copyRecords(w, o.Read.NewReader(src), n)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	return copied, err
+}
+
+// Concat copies every record of each of srcs, in order, to dst.  It returns
+// the number of records copied and the first error encountered, if any.
+func Concat(dst io.Writer, srcs ...io.Reader) (copied int64, err error) {
+	return CopyOptions{}.Concat(dst, srcs...)
+}
+
+// Concat behaves like the package-level Concat function but frames records
+// according to o.
+func (o CopyOptions) Concat(dst io.Writer, srcs ...io.Reader) (copied int64, err error) {
+	w := o.Write.NewWriter(dst)
+	for _, src := range srcs {
+		var n int64
+		n, err = copyRecords(w, o.Read.NewReader(bufio.NewReader(src)), -1)
+		copied += n
+		if err != nil {
+			break
+		}
+	}
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	return copied, err
+}
+
+// copyRecords copies up to n records from r to w, or all of them if n is
+// negative.
+func copyRecords(w *Writer, r *Reader, n int64) (copied int64, err error) {
+	for n < 0 || copied < n {
+		payload, err := r.NextBytes()
+		if err == io.EOF {
+			return copied, nil
+		}
+		if err != nil {
+			return copied, err
+		}
+		if err := w.WriteBytes(payload); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+	return copied, nil
+}
+
+// Split divides the records of src among successive parts, each at most
+// maxBytes long, and returns the number of parts written.  A record that alone
+// exceeds maxBytes occupies a part by itself.  Split calls create to obtain
+// part i, numbered from zero, only once it has a record to write, so an empty
+// src yields no parts; it closes each part once it is full.
+func Split(src io.Reader, maxBytes int64, create func(part int) (io.WriteCloser, error)) (parts int, err error) {
+	return CopyOptions{}.Split(src, maxBytes, create)
+}
+
+// Split behaves like the package-level Split function but frames records
+// according to o.
+func (o CopyOptions) Split(src io.Reader, maxBytes int64, create func(part int) (io.WriteCloser, error)) (parts int, err error) {
+	r := o.Read.NewReader(bufio.NewReader(src))
+	var (
+		part io.WriteCloser
+		w    *Writer
+	)
+	closePart := func() error {
+		if part == nil {
+			return nil
+		}
+		err := w.Flush()
+		if cerr := part.Close(); err == nil {
+			err = cerr
+		}
+		part = nil
+		return err
+	}
+	for {
+		payload, err := r.NextBytes()
+		if err == io.EOF {
+			return parts, closePart()
+		}
+		if err != nil {
+			closePart()
+			return parts, err
+		}
+		if part != nil && w.Offset()+o.frameBound(len(payload)) > maxBytes {
+			if err := closePart(); err != nil {
+				return parts, err
+			}
+		}
+		if part == nil {
+			if part, err = create(parts); err != nil {
+				return parts, err
+			}
+			parts++
+			w = o.Write.NewWriter(part)
+		}
+		if err := w.WriteBytes(payload); err != nil {
+			closePart()
+			return parts, err
+		}
+	}
+}
+
+// frameBound returns the most bytes that writing a payload of size bytes may
+// occupy.
+func (o CopyOptions) frameBound(size int) int64 {
+	if o.Write.Compressed {
+		// Compression never enlarges a payload by more than its codec byte.
+		size++
+	}
+	n := protowire.SizeVarint(uint64(size)) + size
+	if o.Write.Checksum {
+		n += checksumLen
+	}
+	return int64(n)
+}
+
+// Sample copies a uniform random sample of k records of src to dst, preserving
+// their order in src, and returns the number of records copied, which is fewer
+// than k only if src holds fewer than k records.  The sampled records are held
+// in memory until src is exhausted.  Randomness comes from rng, or from the
+// math/rand top-level functions if rng is nil.
+func Sample(dst io.Writer, src io.Reader, k int, rng *rand.Rand) (copied int, err error) {
+	return CopyOptions{}.Sample(dst, src, k, rng)
+}
+
+// Sample behaves like the package-level Sample function but frames records
+// according to o.
+func (o CopyOptions) Sample(dst io.Writer, src io.Reader, k int, rng *rand.Rand) (copied int, err error) {
+	int63n := rand.Int63n
+	if rng != nil {
+		int63n = rng.Int63n
+	}
+	type sampled struct {
+		index   int64
+		payload []byte
+	}
+	// Reservoir sampling: record i replaces a random member of the reservoir
+	// with probability k/(i+1).
+	var reservoir []sampled
+	r := o.Read.NewReader(bufio.NewReader(src))
+	for i := int64(0); ; i++ {
+		payload, err := r.NextBytes()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		if len(reservoir) < k {
+			reservoir = append(reservoir, sampled{i, append([]byte(nil), payload...)})
+			continue
+		}
+		if j := int63n(i + 1); j < int64(k) {
+			reservoir[j] = sampled{i, append(reservoir[j].payload[:0], payload...)}
+		}
+	}
+	sort.Slice(reservoir, func(i, j int) bool { return reservoir[i].index < reservoir[j].index })
+	w := o.Write.NewWriter(dst)
+	for _, s := range reservoir {
+		if err := w.WriteBytes(s.payload); err != nil {
+			return copied, err
+		}
+		copied++
+	}
+	return copied, w.Flush()
+}
@@ -0,0 +1,255 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// recordStream returns a stream of n Records of growing size written with
+// opts.
+func recordStream(t *testing.T, opts WriteDelimitedOptions, n int) ([]byte, []*testdata.Record) {
+	t.Helper()
+	return writeFixture(t, n, opts.NewWriter, growingRecord)
+}
+
+// decodeStream decodes every Record of stream with opts.
+func decodeStream(t *testing.T, opts ReadDelimitedOptions, stream []byte) []*testdata.Record {
+	t.Helper()
+	r := opts.NewReader(bytes.NewReader(stream))
+	var got []*testdata.Record
+	for {
+		msg := new(testdata.Record)
+		err := r.Next(msg)
+		if err == io.EOF {
+			return got
+		}
+		if err != nil {
+			t.Fatalf("r.Next(msg) for record %d = %v, want nil", len(got), err)
+		}
+		got = append(got, msg)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	stream, data := recordStream(t, WriteDelimitedOptions{}, 10)
+	for _, test := range []struct {
+		name string
+		n    int64
+		want int64
+	}{
+		{name: "none", n: 0, want: 0},
+		{name: "some", n: 3, want: 3},
+		{name: "all", n: -1, want: 10},
+		{name: "more than all", n: 20, want: 10},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			src := bytes.NewReader(stream)
+			var dst bytes.Buffer
+			copied, err := Copy(&dst, src, test.n)
+			if copied != test.want || err != nil {
+				t.Fatalf("Copy(&dst, src, %d) = %d, %v; want %d, nil", test.n, copied, err, test.want)
+			}
+			if got, want := decodeStream(t, ReadDelimitedOptions{}, dst.Bytes()), data[:test.want]; !cmp.Equal(got, want, protocmp.Transform(), cmpopts.EquateEmpty()) {
+				t.Errorf("Copy(&dst, src, %d) wrote %v, want %v", test.n, got, want)
+			}
+			// The source is consumed no further than the records copied.
+			if rest := decodeStream(t, ReadDelimitedOptions{}, stream[len(stream)-src.Len():]); !cmp.Equal(rest, data[test.want:], protocmp.Transform(), cmpopts.EquateEmpty()) {
+				t.Errorf("after Copy(&dst, src, %d), src holds %v, want %v", test.n, rest, data[test.want:])
+			}
+		})
+	}
+}
+
+func TestCopyReframes(t *testing.T) {
+	stream, data := recordStream(t, WriteDelimitedOptions{Compressed: true, Codec: CodecFlate}, 50)
+	opts := CopyOptions{
+		Read:  ReadDelimitedOptions{Compressed: true},
+		Write: WriteDelimitedOptions{Checksum: true},
+	}
+	var dst bytes.Buffer
+	if copied, err := opts.Copy(&dst, bytes.NewReader(stream), -1); copied != 50 || err != nil {
+		t.Fatalf("Copy(&dst, stream, -1) = %d, %v; want 50, nil", copied, err)
+	}
+	if got := decodeStream(t, ReadDelimitedOptions{Checksum: true}, dst.Bytes()); !cmp.Equal(got, data, protocmp.Transform()) {
+		t.Errorf("Copy(&dst, stream, -1) wrote %v, want %v", got, data)
+	}
+}
+
+func TestCopyCorrupt(t *testing.T) {
+	stream, data := recordStream(t, WriteDelimitedOptions{}, 5)
+	var dst bytes.Buffer
+	copied, err := Copy(&dst, bytes.NewReader(stream[:len(stream)-1]), -1)
+	if copied != 4 || !matchErr(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Copy(&dst, truncated, -1) = %d, %v; want 4, %v", copied, err, io.ErrUnexpectedEOF)
+	}
+	if got := decodeStream(t, ReadDelimitedOptions{}, dst.Bytes()); !cmp.Equal(got, data[:4], protocmp.Transform()) {
+		t.Errorf("Copy(&dst, truncated, -1) wrote %v, want %v", got, data[:4])
+	}
+}
+
+func TestConcat(t *testing.T) {
+	a, aData := recordStream(t, WriteDelimitedOptions{}, 3)
+	b, bData := recordStream(t, WriteDelimitedOptions{}, 4)
+	var dst bytes.Buffer
+	copied, err := Concat(&dst, bytes.NewReader(a), bytes.NewReader(nil), bytes.NewReader(b))
+	if copied != 7 || err != nil {
+		t.Fatalf("Concat(&dst, a, empty, b) = %d, %v; want 7, nil", copied, err)
+	}
+	if want := append(append([]byte(nil), a...), b...); !bytes.Equal(dst.Bytes(), want) {
+		t.Errorf("Concat(&dst, a, empty, b) wrote %v, want %v", dst.Bytes(), want)
+	}
+	if got, want := decodeStream(t, ReadDelimitedOptions{}, dst.Bytes()), append(aData, bData...); !cmp.Equal(got, want, protocmp.Transform()) {
+		t.Errorf("Concat(&dst, a, empty, b) wrote %v, want %v", got, want)
+	}
+}
+
+// bufferCloser is a bytes.Buffer that records whether it was closed.
+type bufferCloser struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (b *bufferCloser) Close() error {
+	b.closed = true
+	return nil
+}
+
+func TestSplit(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		opts     CopyOptions
+		maxBytes int64
+	}{
+		{name: "plain", maxBytes: 64},
+		{name: "tiny parts", maxBytes: 1},
+		{
+			name:     "checksummed and compressed",
+			opts:     CopyOptions{Read: ReadDelimitedOptions{Checksum: true}, Write: WriteDelimitedOptions{Checksum: true, Compressed: true, Codec: CodecGzip}},
+			maxBytes: 100,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			stream, data := recordStream(t, WriteDelimitedOptions{Checksum: test.opts.Read.Checksum}, 30)
+			var parts []*bufferCloser
+			n, err := test.opts.Split(bytes.NewReader(stream), test.maxBytes, func(part int) (io.WriteCloser, error) {
+				if part != len(parts) {
+					t.Errorf("create(%d), want create(%d)", part, len(parts))
+				}
+				parts = append(parts, new(bufferCloser))
+				return parts[part], nil
+			})
+			if err != nil || n != len(parts) {
+				t.Fatalf("Split(stream, %d, create) = %d, %v; want %d, nil", test.maxBytes, n, err, len(parts))
+			}
+			if n < 2 {
+				t.Errorf("Split(stream, %d, create) = %d parts, want several", test.maxBytes, n)
+			}
+			var got []*testdata.Record
+			for i, part := range parts {
+				if !part.closed {
+					t.Errorf("part %d not closed", i)
+				}
+				records := decodeStream(t, ReadDelimitedOptions{Checksum: test.opts.Write.Checksum, Compressed: test.opts.Write.Compressed}, part.Bytes())
+				if len(records) > 1 && int64(part.Len()) > test.maxBytes {
+					t.Errorf("part %d holds %d records in %d bytes, want at most %d bytes", i, len(records), part.Len(), test.maxBytes)
+				}
+				got = append(got, records...)
+			}
+			if !cmp.Equal(got, data, protocmp.Transform()) {
+				t.Errorf("Split(stream, %d, create) parts hold %v, want %v", test.maxBytes, got, data)
+			}
+		})
+	}
+}
+
+func TestSplitEmpty(t *testing.T) {
+	n, err := Split(bytes.NewReader(nil), 10, func(part int) (io.WriteCloser, error) {
+		t.Errorf("create(%d) called for empty stream", part)
+		return new(bufferCloser), nil
+	})
+	if n != 0 || err != nil {
+		t.Errorf("Split(empty, 10, create) = %d, %v; want 0, nil", n, err)
+	}
+}
+
+func TestSample(t *testing.T) {
+	stream, data := recordStream(t, WriteDelimitedOptions{}, 100)
+	for _, test := range []struct {
+		name string
+		k    int
+		want int
+	}{
+		{name: "none", k: 0, want: 0},
+		{name: "some", k: 10, want: 10},
+		{name: "more than all", k: 200, want: 100},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var dst bytes.Buffer
+			copied, err := Sample(&dst, bytes.NewReader(stream), test.k, rand.New(rand.NewSource(1)))
+			if copied != test.want || err != nil {
+				t.Fatalf("Sample(&dst, stream, %d, rng) = %d, %v; want %d, nil", test.k, copied, err, test.want)
+			}
+			got := decodeStream(t, ReadDelimitedOptions{}, dst.Bytes())
+			if len(got) != test.want {
+				t.Fatalf("Sample(&dst, stream, %d, rng) wrote %d records, want %d", test.k, len(got), test.want)
+			}
+			prev := -1
+			for _, msg := range got {
+				i := int(msg.GetFirst())
+				if i <= prev {
+					t.Fatalf("Sample(&dst, stream, %d, rng) wrote record %d after %d, want stream order", test.k, i, prev)
+				}
+				if !cmp.Equal(msg, data[i], protocmp.Transform()) {
+					t.Errorf("sampled record = %v, want %v", msg, data[i])
+				}
+				prev = i
+			}
+		})
+	}
+}
+
+func TestSampleUniform(t *testing.T) {
+	stream, _ := recordStream(t, WriteDelimitedOptions{}, 4)
+	rng := rand.New(rand.NewSource(1))
+	counts := make(map[uint64]int)
+	const trials = 4000
+	for i := 0; i < trials; i++ {
+		var dst bytes.Buffer
+		if _, err := Sample(&dst, bytes.NewReader(stream), 1, rng); err != nil {
+			t.Fatal(err)
+		}
+		var msg testdata.Record
+		if _, err := ReadDelimited(&dst, &msg); err != nil {
+			t.Fatal(err)
+		}
+		counts[msg.GetFirst()]++
+	}
+	for i := uint64(0); i < 4; i++ {
+		// Each record is expected 1000 times; 800 is over six standard
+		// deviations away.
+		if counts[i] < 800 {
+			t.Errorf("record %d sampled %d of %d times, want about %d", i, counts[i], trials, trials/4)
+		}
+	}
+}
@@ -15,9 +15,12 @@
 package pbutil
 
 import (
+	"encoding/binary"
+	"fmt"
 	"io"
+	"math"
 
-	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protowire"
 	"google.golang.org/protobuf/proto"
 )
 
@@ -26,7 +29,7 @@ type countingReader struct {
 	n int
 }
 
-// implements protodelim.Reader
+// implements io.Reader
 func (r *countingReader) Read(p []byte) (n int, err error) {
 	n, err = r.r.Read(p)
 	if n > 0 {
@@ -35,7 +38,7 @@ func (r *countingReader) Read(p []byte) (n int, err error) {
 	return n, err
 }
 
-// implements protodelim.Reader
+// implements io.ByteReader
 func (c *countingReader) ReadByte() (byte, error) {
 	var buf [1]byte
 	for {
@@ -46,8 +49,191 @@ func (c *countingReader) ReadByte() (byte, error) {
 			// indicate EOF.
 			continue
 		}
-		return buf[0], err
+		if n == 1 {
+			// io.Reader permits returning the final bytes alongside io.EOF.  The
+			// byte is valid, and the EOF will be reported again by the next call.
+			return buf[0], nil
+		}
+		return 0, err
+	}
+}
+
+// readHeader reads the varint length prefix of a record from r.  It returns
+// io.EOF only if no bytes could be read at all.
+func readHeader(r io.ByteReader) (size uint64, err error) {
+	var arr [binary.MaxVarintLen64]byte
+	buf := arr[:0]
+	for i := range arr {
+		b, err := r.ReadByte()
+		if err != nil {
+			// Immediate EOF is a clean end of stream; anything else is truncation,
+			// which protowire.ConsumeVarint reports below.
+			if err == io.EOF && i != 0 {
+				break
+			}
+			return 0, err
+		}
+		buf = append(buf, b)
+		if b < 0x80 {
+			break
+		}
+	}
+	size, n := protowire.ConsumeVarint(buf)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return size, nil
+}
+
+// maxRecordSize is the largest payload that can hold a valid message; the
+// Protocol Buffer wire format does not support messages of 2 GiB or more.
+const maxRecordSize = math.MaxInt32
+
+// SizeTooLargeError is returned when a record's length prefix declares a size
+// larger than the configured ReadDelimitedOptions.MaxSize.
+type SizeTooLargeError struct {
+	// Size is the length declared by the record's varint prefix.
+	Size uint64
+	// MaxSize is the limit that Size exceeded.
+	MaxSize int64
+	// Discarded reports whether the oversized record's payload was consumed
+	// from the stream, leaving it positioned at the following record.
+	Discarded bool
+}
+
+func (e *SizeTooLargeError) Error() string {
+	return fmt.Sprintf("pbutil: record size %d exceeds maximum %d", e.Size, e.MaxSize)
+}
+
+// ReadDelimitedOptions configures how length-delimited records are read.  The
+// zero value reads records of any size, as ReadDelimited does.
+type ReadDelimitedOptions struct {
+	// MaxSize is the maximum permitted size in bytes of a record's payload,
+	// excluding its length prefix.  Records declaring a larger size are
+	// rejected with a *SizeTooLargeError before any buffer for the payload is
+	// allocated.  A MaxSize of zero or less imposes no limit beyond the 2 GiB
+	// ceiling of the wire format itself.
+	MaxSize int64
+
+	// DiscardOversized causes the payload of a record rejected due to MaxSize
+	// to be read and thrown away, so that the next read begins at the
+	// following record.  Without it, the stream is left positioned directly
+	// after the rejected record's length prefix.
+	DiscardOversized bool
+
+	// Checksum expects each payload to be followed by the CRC-32C checksum
+	// written under WriteDelimitedOptions.Checksum, and verifies it.  A
+	// mismatch is reported as a *ChecksumError.
+	Checksum bool
+
+	// Compressed expects each payload to begin with the codec byte written
+	// under WriteDelimitedOptions.Compressed, and decompresses the remainder
+	// before it is decoded or returned.  MaxSize bounds both the stored and
+	// the decompressed size of each payload.
+	Compressed bool
+}
+
+// scratch holds the buffers reused across reads by a Reader.
+type scratch struct {
+	stored, plain []byte
+}
+
+// trailerLen returns the number of bytes that follow each payload.
+func (o ReadDelimitedOptions) trailerLen() uint64 {
+	if o.Checksum {
+		return checksumLen
+	}
+	return 0
+}
+
+// ReadDelimited behaves like the package-level ReadDelimited function but
+// honors the options in o.
+func (o ReadDelimitedOptions) ReadDelimited(r io.Reader, m proto.Message) (n int, err error) {
+	cr := &countingReader{r: r}
+	buf, err := o.readPayload(cr, new(scratch), 0)
+	if err != nil {
+		return cr.n, err
 	}
+	return cr.n, unmarshalFrame(0, buf, m)
+}
+
+// readPayload reads the next record from cr and returns its message bytes,
+// decompressing them if the options call for it.  The result aliases s, which
+// retains any buffers grown in the process.
+func (o ReadDelimitedOptions) readPayload(cr *countingReader, s *scratch, offset int64) ([]byte, error) {
+	stored, err := o.readFrame(cr, s.stored, offset)
+	if cap(stored) > cap(s.stored) {
+		s.stored = stored[:0]
+	}
+	if err != nil || !o.Compressed {
+		return stored, err
+	}
+	plain, err := decompressPayload(s.plain[:0], stored, int(o.maxSize()))
+	if cap(plain) > cap(s.plain) {
+		s.plain = plain[:0]
+	}
+	if err != nil {
+		return nil, frameError(offset, StageDecompress, uint64(len(stored)), err)
+	}
+	return plain, nil
+}
+
+// maxSize returns the effective limit on the size of a payload.
+func (o ReadDelimitedOptions) maxSize() int64 {
+	if o.MaxSize <= 0 || o.MaxSize > maxRecordSize {
+		return maxRecordSize
+	}
+	return o.MaxSize
+}
+
+// readFrame reads the next record's payload from cr, reusing buf for storage
+// when it has sufficient capacity.  Errors other than a clean end of stream are
+// reported as a *FrameError for the record beginning at offset.
+func (o ReadDelimitedOptions) readFrame(cr *countingReader, buf []byte, offset int64) ([]byte, error) {
+	size, err := readHeader(cr)
+	if err != nil {
+		return nil, frameError(offset, StageHeader, 0, err)
+	}
+	maxSize := o.maxSize()
+	if size > uint64(maxSize) {
+		tooLarge := &SizeTooLargeError{Size: size, MaxSize: maxSize}
+		if o.DiscardOversized {
+			if err := discard(cr, size+o.trailerLen()); err != nil {
+				return nil, frameError(offset, StagePayload, size, err)
+			}
+			tooLarge.Discarded = true
+		}
+		return nil, frameError(offset, StageSize, size, tooLarge)
+	}
+	if uint64(cap(buf)) < size {
+		buf = make([]byte, size)
+	}
+	buf = buf[:size]
+	if _, err := io.ReadFull(cr, buf); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, frameError(offset, StagePayload, size, err)
+	}
+	if o.Checksum {
+		if err := verifyChecksum(cr, buf); err != nil {
+			return nil, frameError(offset, StageChecksum, size, err)
+		}
+	}
+	return buf, nil
+}
+
+// discard consumes size bytes from r, reporting io.ErrUnexpectedEOF if the
+// stream ends first.
+func discard(r io.Reader, size uint64) error {
+	if size > math.MaxInt64 {
+		size = math.MaxInt64
+	}
+	n, err := io.CopyN(io.Discard, r, int64(size))
+	if err == io.EOF || err == nil && uint64(n) < size {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
 }
 
 // ReadDelimited decodes a message from the provided length-delimited stream,
@@ -60,12 +246,12 @@ func (c *countingReader) ReadByte() (byte, error) {
 // reads more bytes from the stream than required.  The function never returns
 // an error if a message has been read and decoded correctly, even if the end
 // of the stream has been reached in doing so.  In that case, any subsequent
-// calls return (0, io.EOF).
+// calls return (0, io.EOF).  Any other error is reported as a *FrameError
+// identifying the part of the record that could not be read.
+//
+// ReadDelimited places no limit on the size of a record.  Use
+// ReadDelimitedOptions to bound the memory that a corrupt or hostile length
+// prefix can cause to be allocated.
 func ReadDelimited(r io.Reader, m proto.Message) (n int, err error) {
-	cr := &countingReader{r: r}
-	opts := protodelim.UnmarshalOptions{
-		MaxSize: -1,
-	}
-	err = opts.UnmarshalFrom(cr, m)
-	return cr.n, err
+	return ReadDelimitedOptions{}.ReadDelimited(r, m)
 }
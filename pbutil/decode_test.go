@@ -136,3 +136,90 @@ func TestFirstNoop(t *testing.T) {
 		t.Errorf("ReadDelimited(r, &msg) msg = %v, want %v", got, want)
 	}
 }
+
+func TestReadDelimitedOptionsMaxSize(t *testing.T) {
+	// A 3-byte record followed by a 1-byte record.
+	data := []byte{3, 8, 1, 0, 1, 0}
+	for _, test := range []struct {
+		name      string
+		opts      ReadDelimitedOptions
+		n         int
+		discarded bool
+	}{
+		{
+			name: "reject",
+			opts: ReadDelimitedOptions{MaxSize: 2},
+			n:    1,
+		},
+		{
+			name:      "discard",
+			opts:      ReadDelimitedOptions{MaxSize: 2, DiscardOversized: true},
+			n:         4,
+			discarded: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			r := bytes.NewReader(data)
+			var msg testdata.Record
+			n, err := test.opts.ReadDelimited(r, &msg)
+			if got, want := n, test.n; !cmp.Equal(got, want) {
+				t.Errorf("ReadDelimited(%v, &msg) = %v, ?; want %v, ?", data, got, want)
+			}
+			var tooLarge *SizeTooLargeError
+			if !errors.As(err, &tooLarge) {
+				t.Fatalf("ReadDelimited(%v, &msg) = ?, %v; want ?, *SizeTooLargeError", data, err)
+			}
+			if got, want := tooLarge, (&SizeTooLargeError{Size: 3, MaxSize: 2, Discarded: test.discarded}); !cmp.Equal(got, want) {
+				t.Errorf("ReadDelimited(%v, &msg) err = %v, want %v", data, got, want)
+			}
+			if got, want := r.Len(), len(data)-test.n; got != want {
+				t.Errorf("after ReadDelimited(%v, &msg), %d bytes remain; want %d", data, got, want)
+			}
+		})
+	}
+}
+
+func TestReadDelimitedOptionsDiscardContinues(t *testing.T) {
+	data := []byte{3, 8, 1, 0, 2, 8, 2}
+	r := bytes.NewReader(data)
+	opts := ReadDelimitedOptions{MaxSize: 2, DiscardOversized: true}
+	var msg testdata.Record
+	if _, err := opts.ReadDelimited(r, &msg); err == nil {
+		t.Fatalf("ReadDelimited(r, &msg) = ?, nil; want ?, *SizeTooLargeError")
+	}
+	n, err := opts.ReadDelimited(r, &msg)
+	if got, want := n, 3; got != want {
+		t.Errorf("ReadDelimited(r, &msg) = %v, ?; want %v, ?", got, want)
+	}
+	if err != nil {
+		t.Errorf("ReadDelimited(r, &msg) = ?, %v; want ?, nil", err)
+	}
+	if got, want := &msg, (&testdata.Record{First: proto.Uint64(2)}); !cmp.Equal(got, want, protocmp.Transform()) {
+		t.Errorf("ReadDelimited(r, &msg) msg = %v, want %v", got, want)
+	}
+}
+
+func TestReadDelimitedOptionsDiscardTruncated(t *testing.T) {
+	data := []byte{5, 8, 1}
+	opts := ReadDelimitedOptions{MaxSize: 2, DiscardOversized: true}
+	n, err := opts.ReadDelimited(bytes.NewReader(data), nil)
+	if got, want := n, 3; got != want {
+		t.Errorf("ReadDelimited(%v, nil) = %v, ?; want %v, ?", data, got, want)
+	}
+	if got, want := err, io.ErrUnexpectedEOF; !errors.Is(got, want) {
+		t.Errorf("ReadDelimited(%v, nil) = ?, %v; want ?, %v", data, got, want)
+	}
+}
+
+func TestReadDelimitedWireLimit(t *testing.T) {
+	// The header declares a 4 GiB payload, which no message can occupy.
+	data := []byte{128, 128, 128, 128, 16}
+	n, err := ReadDelimited(bytes.NewReader(data), nil)
+	if got, want := n, 5; got != want {
+		t.Errorf("ReadDelimited(%v, nil) = %v, ?; want %v, ?", data, got, want)
+	}
+	var tooLarge *SizeTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("ReadDelimited(%v, nil) = ?, %v; want ?, *SizeTooLargeError", data, err)
+	}
+}
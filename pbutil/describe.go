@@ -0,0 +1,194 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// A self-describing stream begins with a header record that names the type of
+// the records that follow and carries the descriptors needed to decode them.
+// The header's payload is encoded as if it were this message:
+//
+//	message StreamHeader {
+//	  string magic = 1;  // Always "pbutil.StreamHeader/v1".
+//	  string message_name = 2;
+//	  google.protobuf.FileDescriptorSet file_descriptor_set = 3;
+//	}
+const (
+	headerMagic      = "pbutil.StreamHeader/v1"
+	headerMagicField = 1
+	headerNameField  = 2
+	headerFilesField = 3
+)
+
+// ErrNoDescriptorHeader is returned when a stream does not begin with the
+// header of a self-describing stream.
+var ErrNoDescriptorHeader = errors.New("pbutil: stream lacks a descriptor header")
+
+// NewDescribedWriter returns a Writer for records of the type md after writing
+// a header describing that type, making the stream decodable without
+// generated code.  The header includes md's file and all of its transitive
+// dependencies.  It is counted by Offset but not by Index.
+func NewDescribedWriter(w io.Writer, md protoreflect.MessageDescriptor) (*Writer, error) {
+	return WriteDelimitedOptions{}.NewDescribedWriter(w, md)
+}
+
+// NewDescribedWriter behaves like the package-level NewDescribedWriter
+// function but frames the header and records according to o.
+func (o WriteDelimitedOptions) NewDescribedWriter(w io.Writer, md protoreflect.MessageDescriptor) (*Writer, error) {
+	header, err := marshalHeader(md)
+	if err != nil {
+		return nil, err
+	}
+	wr := o.NewWriter(w)
+	if err := wr.writeFrame(header); err != nil {
+		return nil, err
+	}
+	wr.index = 0
+	return wr, nil
+}
+
+// NewDescribedReader reads the header of a self-describing stream from r and
+// returns a Reader positioned at the first record, along with the type of the
+// records.  The type is backed by dynamicpb and is independent of any
+// generated code or global registry.  The header is counted by Offset but not
+// by Index.
+func NewDescribedReader(r io.Reader) (*Reader, protoreflect.MessageType, error) {
+	return ReadDelimitedOptions{}.NewDescribedReader(r)
+}
+
+// NewDescribedReader behaves like the package-level NewDescribedReader
+// function but frames the header and records according to o.
+func (o ReadDelimitedOptions) NewDescribedReader(r io.Reader) (*Reader, protoreflect.MessageType, error) {
+	rd := o.NewReader(r)
+	header, err := rd.nextFrame()
+	if err == io.EOF {
+		err = ErrNoDescriptorHeader
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	mt, err := unmarshalHeader(header)
+	if err != nil {
+		return nil, nil, err
+	}
+	rd.index = 0
+	return rd, mt, nil
+}
+
+// marshalHeader encodes the header describing md.
+func marshalHeader(md protoreflect.MessageDescriptor) ([]byte, error) {
+	files, err := proto.Marshal(fileDescriptorSet(md.ParentFile()))
+	if err != nil {
+		return nil, err
+	}
+	var b []byte
+	b = protowire.AppendTag(b, headerMagicField, protowire.BytesType)
+	b = protowire.AppendString(b, headerMagic)
+	b = protowire.AppendTag(b, headerNameField, protowire.BytesType)
+	b = protowire.AppendString(b, string(md.FullName()))
+	b = protowire.AppendTag(b, headerFilesField, protowire.BytesType)
+	b = protowire.AppendBytes(b, files)
+	return b, nil
+}
+
+// unmarshalHeader decodes a header and resolves the message type it names.
+func unmarshalHeader(b []byte) (protoreflect.MessageType, error) {
+	var magic, name string
+	var files []byte
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, ErrNoDescriptorHeader
+		}
+		b = b[n:]
+		if typ != protowire.BytesType {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		} else {
+			var v []byte
+			v, n = protowire.ConsumeBytes(b)
+			switch num {
+			case headerMagicField:
+				magic = string(v)
+			case headerNameField:
+				name = string(v)
+			case headerFilesField:
+				files = v
+			}
+		}
+		if n < 0 {
+			return nil, ErrNoDescriptorHeader
+		}
+		b = b[n:]
+	}
+	if magic != headerMagic {
+		return nil, ErrNoDescriptorHeader
+	}
+	fds := new(descriptorpb.FileDescriptorSet)
+	if err := proto.Unmarshal(files, fds); err != nil {
+		return nil, fmt.Errorf("pbutil: decoding descriptor header: %w", err)
+	}
+	return MessageTypeFromSet(fds, protoreflect.FullName(name))
+}
+
+// MessageTypeFromSet resolves the message named name among the files of fds,
+// which must include all of their dependencies, and returns a dynamicpb type
+// for it.
+func MessageTypeFromSet(fds *descriptorpb.FileDescriptorSet, name protoreflect.FullName) (protoreflect.MessageType, error) {
+	files, err := protodesc.NewFiles(fds)
+	if err != nil {
+		return nil, fmt.Errorf("pbutil: resolving descriptors: %w", err)
+	}
+	d, err := files.FindDescriptorByName(name)
+	if err != nil {
+		return nil, fmt.Errorf("pbutil: resolving %s: %w", name, err)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("pbutil: %s is not a message", name)
+	}
+	return dynamicpb.NewMessageType(md), nil
+}
+
+// fileDescriptorSet returns a FileDescriptorSet holding fd and its transitive
+// dependencies, each listed after the files it depends upon.
+func fileDescriptorSet(fd protoreflect.FileDescriptor) *descriptorpb.FileDescriptorSet {
+	fds := new(descriptorpb.FileDescriptorSet)
+	seen := make(map[string]bool)
+	var visit func(protoreflect.FileDescriptor)
+	visit = func(fd protoreflect.FileDescriptor) {
+		if seen[fd.Path()] {
+			return
+		}
+		seen[fd.Path()] = true
+		imports := fd.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			visit(imports.Get(i).FileDescriptor)
+		}
+		fds.File = append(fds.File, protodesc.ToFileDescriptorProto(fd))
+	}
+	visit(fd)
+	return fds
+}
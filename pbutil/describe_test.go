@@ -0,0 +1,200 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/apipb"
+)
+
+func TestDescribedRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		wopts WriteDelimitedOptions
+		ropts ReadDelimitedOptions
+	}{
+		{
+			name: "plain",
+		},
+		{
+			name:  "checksummed and compressed",
+			wopts: WriteDelimitedOptions{Checksum: true, Compressed: true, Codec: CodecGzip},
+			ropts: ReadDelimitedOptions{Checksum: true, Compressed: true},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data := []*testdata.Record{
+				{First: proto.Uint64(1)},
+				{Third: proto.String("three")},
+				{},
+			}
+			var buf bytes.Buffer
+			w, err := test.wopts.NewDescribedWriter(&buf, (*testdata.Record)(nil).ProtoReflect().Descriptor())
+			if err != nil {
+				t.Fatalf("NewDescribedWriter(&buf, Record) = ?, %v; want ?, nil", err)
+			}
+			if got := w.Index(); got != 0 {
+				t.Errorf("w.Index() = %d, want 0", got)
+			}
+			for _, msg := range data {
+				if err := w.Write(msg); err != nil {
+					t.Fatalf("w.Write(%v) = %v, want nil", msg, err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("w.Close() = %v, want nil", err)
+			}
+
+			r, mt, err := test.ropts.NewDescribedReader(bytes.NewReader(buf.Bytes()))
+			if err != nil {
+				t.Fatalf("NewDescribedReader(buf) = ?, ?, %v; want ?, ?, nil", err)
+			}
+			if got, want := mt.Descriptor().FullName(), protoreflect.FullName("testdata.Record"); got != want {
+				t.Errorf("NewDescribedReader(buf) type = %v, want %v", got, want)
+			}
+			if _, ok := mt.New().Interface().(*testdata.Record); ok {
+				t.Errorf("NewDescribedReader(buf) type is generated, want dynamic")
+			}
+			if r.Offset() == 0 || r.Index() != 0 {
+				t.Errorf("after header: r.Offset(), r.Index() = %d, %d; want >0, 0", r.Offset(), r.Index())
+			}
+			for i, want := range data {
+				msg := mt.New().Interface()
+				if err := r.Next(msg); err != nil {
+					t.Fatalf("r.Next(msg) for record %d = %v, want nil", i, err)
+				}
+				// Round trip through the wire format to compare against the
+				// generated type.
+				b, err := proto.Marshal(msg)
+				if err != nil {
+					t.Fatalf("proto.Marshal(record %d) = ?, %v; want ?, nil", i, err)
+				}
+				got := new(testdata.Record)
+				if err := proto.Unmarshal(b, got); err != nil {
+					t.Fatalf("proto.Unmarshal(record %d) = %v, want nil", i, err)
+				}
+				if !cmp.Equal(got, want, protocmp.Transform()) {
+					t.Errorf("record %d = %v, want %v", i, got, want)
+				}
+			}
+			if err := r.Next(mt.New().Interface()); err != io.EOF {
+				t.Errorf("r.Next(msg) at end = %v, want io.EOF", err)
+			}
+			if got, want := r.Index(), int64(len(data)); got != want {
+				t.Errorf("r.Index() = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestDescribedDependencies(t *testing.T) {
+	// api.proto imports type.proto, which in turn imports any.proto, so the
+	// header must carry the transitive closure, dependencies first.
+	md := (*apipb.Api)(nil).ProtoReflect().Descriptor()
+	var got []string
+	for _, f := range fileDescriptorSet(md.ParentFile()).GetFile() {
+		got = append(got, f.GetName())
+	}
+	want := []string{
+		"google/protobuf/source_context.proto",
+		"google/protobuf/any.proto",
+		"google/protobuf/type.proto",
+		"google/protobuf/api.proto",
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("fileDescriptorSet(%s) files = %v, want %v", md.ParentFile().Path(), got, want)
+	}
+
+	var buf bytes.Buffer
+	w, err := NewDescribedWriter(&buf, md)
+	if err != nil {
+		t.Fatalf("NewDescribedWriter(&buf, Api) = ?, %v; want ?, nil", err)
+	}
+	api := &apipb.Api{Name: "svc", Methods: []*apipb.Method{{Name: "Get"}}}
+	if err := w.Write(api); err != nil {
+		t.Fatalf("w.Write(%v) = %v, want nil", api, err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+	r, mt, err := NewDescribedReader(&buf)
+	if err != nil {
+		t.Fatalf("NewDescribedReader(buf) = ?, ?, %v; want ?, ?, nil", err)
+	}
+	msg := mt.New()
+	if err := r.Next(msg.Interface()); err != nil {
+		t.Fatalf("r.Next(msg) = %v, want nil", err)
+	}
+	methods := msg.Get(mt.Descriptor().Fields().ByName("methods")).List()
+	if methods.Len() != 1 {
+		t.Fatalf("msg.methods has %d elements, want 1", methods.Len())
+	}
+	name := methods.Get(0).Message().Get(mt.Descriptor().Fields().ByName("methods").Message().Fields().ByName("name"))
+	if got := name.String(); got != "Get" {
+		t.Errorf("msg.methods[0].name = %q, want %q", got, "Get")
+	}
+}
+
+func TestDescribedReaderNoHeader(t *testing.T) {
+	var plain bytes.Buffer
+	if _, err := WriteDelimited(&plain, &testdata.Record{Third: proto.String("not a header")}); err != nil {
+		t.Fatalf("WriteDelimited(&plain, record) = ?, %v; want ?, nil", err)
+	}
+	for _, test := range []struct {
+		name  string
+		input []byte
+	}{
+		{name: "empty"},
+		{name: "ordinary record", input: plain.Bytes()},
+		{name: "malformed payload", input: []byte{2, 0xff, 0xff}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, _, err := NewDescribedReader(bytes.NewReader(test.input))
+			if !errors.Is(err, ErrNoDescriptorHeader) {
+				t.Errorf("NewDescribedReader(%v) = ?, ?, %v; want ?, ?, ErrNoDescriptorHeader", test.input, err)
+			}
+		})
+	}
+}
+
+func TestMessageTypeFromSet(t *testing.T) {
+	fds := fileDescriptorSet((*testdata.Record)(nil).ProtoReflect().Descriptor().ParentFile())
+	for _, test := range []struct {
+		name    protoreflect.FullName
+		wantErr bool
+	}{
+		{name: "testdata.Record"},
+		{name: "testdata.Missing", wantErr: true},
+		{name: "testdata", wantErr: true},
+	} {
+		mt, err := MessageTypeFromSet(fds, test.name)
+		if gotErr := err != nil; gotErr != test.wantErr {
+			t.Errorf("MessageTypeFromSet(fds, %q) = ?, %v; want error %t", test.name, err, test.wantErr)
+			continue
+		}
+		if err == nil && mt.Descriptor().FullName() != test.name {
+			t.Errorf("MessageTypeFromSet(fds, %q) type = %v", test.name, mt.Descriptor().FullName())
+		}
+	}
+}
@@ -30,3 +30,36 @@ import (
 func WriteDelimited(w io.Writer, m proto.Message) (n int, err error) {
 	return protodelim.MarshalTo(w, m)
 }
+
+// WriteDelimitedOptions configures how length-delimited records are written.
+// The zero value produces exactly the framing of WriteDelimited.
+type WriteDelimitedOptions struct {
+	// Checksum follows each payload with its CRC-32C checksum, stored as a
+	// 4-byte little-endian integer outside of the length prefix.  Streams
+	// written this way must be read with ReadDelimitedOptions.Checksum set,
+	// and are not compatible with other implementations of the
+	// length-delimited format.
+	Checksum bool
+
+	// Compressed prefixes each payload with a codec byte and compresses the
+	// remainder with Codec.  A record that compression would make larger is
+	// stored under CodecNone instead.  The checksum, if enabled,
+	// covers the stored form.  Streams written this way must be read with
+	// ReadDelimitedOptions.Compressed set, and are not compatible with other
+	// implementations of the length-delimited format.
+	Compressed bool
+
+	// Codec selects the compression applied to each payload when Compressed
+	// is set.
+	Codec Codec
+}
+
+// WriteDelimited behaves like the package-level WriteDelimited function but
+// honors the options in o.
+func (o WriteDelimitedOptions) WriteDelimited(w io.Writer, m proto.Message) (n int, err error) {
+	payload, err := proto.Marshal(m)
+	if err != nil {
+		return 0, err
+	}
+	return o.WriteDelimitedBytes(w, payload)
+}
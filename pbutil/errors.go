@@ -0,0 +1,111 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Stage identifies the part of a record at which reading it failed.
+type Stage int
+
+const (
+	// StageHeader indicates that the varint length prefix could not be read,
+	// either because it is malformed or because the stream ended within it.
+	StageHeader Stage = iota + 1
+	// StageSize indicates that the length prefix declared a payload larger
+	// than permitted.  The underlying error is a *SizeTooLargeError.
+	StageSize
+	// StagePayload indicates that the payload could not be read in full,
+	// typically because the stream ended before the declared length.
+	StagePayload
+	// StageUnmarshal indicates that the payload was read in full but could
+	// not be decoded into the provided message.
+	StageUnmarshal
+	// StageChecksum indicates that the checksum trailing the payload could
+	// not be read or did not match.  A mismatch is reported as a
+	// *ChecksumError.
+	StageChecksum
+	// StageDecompress indicates that the payload of a compressed record named
+	// an unknown codec or could not be decompressed.
+	StageDecompress
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageHeader:
+		return "header"
+	case StageSize:
+		return "size"
+	case StagePayload:
+		return "payload"
+	case StageUnmarshal:
+		return "unmarshal"
+	case StageChecksum:
+		return "checksum"
+	case StageDecompress:
+		return "decompress"
+	default:
+		return fmt.Sprintf("Stage(%d)", int(s))
+	}
+}
+
+// FrameError describes a failure to read a record from a length-delimited
+// stream.  It wraps the underlying cause, so callers may continue to match
+// conditions like io.ErrUnexpectedEOF with errors.Is, while errors.As
+// exposes where and why the record was rejected.
+//
+// The clean end of a stream is never reported as a FrameError; it remains a
+// bare io.EOF.
+type FrameError struct {
+	// Offset is the position of the record's first byte.  For the
+	// package-level functions this is relative to where the call began
+	// reading, so it is zero; Reader reports positions within the whole
+	// stream.
+	Offset int64
+	// Stage is the part of the record at which reading failed.
+	Stage Stage
+	// DeclaredLen is the payload length declared by the record's prefix.  It
+	// is zero when Stage is StageHeader.
+	DeclaredLen uint64
+	// Err is the underlying cause.
+	Err error
+}
+
+func (e *FrameError) Error() string {
+	if e.Stage == StageHeader {
+		return fmt.Sprintf("pbutil: record at offset %d: %v: %v", e.Offset, e.Stage, e.Err)
+	}
+	return fmt.Sprintf("pbutil: record at offset %d with length %d: %v: %v", e.Offset, e.DeclaredLen, e.Stage, e.Err)
+}
+
+func (e *FrameError) Unwrap() error { return e.Err }
+
+// frameError wraps err in a *FrameError unless it is nil or signals a clean
+// end of stream.
+func frameError(offset int64, stage Stage, declaredLen uint64, err error) error {
+	if err == nil || err == io.EOF && stage == StageHeader {
+		return err
+	}
+	return &FrameError{Offset: offset, Stage: stage, DeclaredLen: declaredLen, Err: err}
+}
+
+// unmarshalFrame decodes the payload of the record at offset into m.
+func unmarshalFrame(offset int64, payload []byte, m proto.Message) error {
+	return frameError(offset, StageUnmarshal, uint64(len(payload)), proto.Unmarshal(payload, m))
+}
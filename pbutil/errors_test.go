@@ -0,0 +1,119 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestFrameErrorStages(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		opts  ReadDelimitedOptions
+		in    []byte
+		want  *FrameError
+		cause error
+	}{
+		{
+			name:  "malformed header",
+			in:    bytes.Repeat([]byte{255}, 11),
+			want:  &FrameError{Stage: StageHeader},
+			cause: errAny,
+		},
+		{
+			name:  "truncated header",
+			in:    []byte{128},
+			want:  &FrameError{Stage: StageHeader},
+			cause: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "oversized",
+			opts: ReadDelimitedOptions{MaxSize: 1},
+			in:   []byte{2, 8, 1},
+			want: &FrameError{Stage: StageSize, DeclaredLen: 2},
+		},
+		{
+			name:  "truncated payload",
+			in:    []byte{5, 8, 1},
+			want:  &FrameError{Stage: StagePayload, DeclaredLen: 5},
+			cause: io.ErrUnexpectedEOF,
+		},
+		{
+			name:  "unparseable payload",
+			in:    []byte{1, 7},
+			want:  &FrameError{Stage: StageUnmarshal, DeclaredLen: 1},
+			cause: proto.Error,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.opts.ReadDelimited(bytes.NewReader(test.in), new(testdata.Record))
+			var got *FrameError
+			if !errors.As(err, &got) {
+				t.Fatalf("ReadDelimited(%v, &msg) = ?, %v; want ?, *FrameError", test.in, err)
+			}
+			if !cmp.Equal(got, test.want, cmpopts.IgnoreFields(FrameError{}, "Err")) {
+				t.Errorf("ReadDelimited(%v, &msg) = ?, %#v; want ?, %#v", test.in, got, test.want)
+			}
+			if test.cause != nil && !matchErr(err, test.cause) {
+				t.Errorf("ReadDelimited(%v, &msg) = ?, %v; want ?, error matching %v", test.in, err, test.cause)
+			}
+		})
+	}
+}
+
+func TestFrameErrorSizeCause(t *testing.T) {
+	opts := ReadDelimitedOptions{MaxSize: 1}
+	_, err := opts.ReadDelimited(bytes.NewReader([]byte{2, 8, 1}), nil)
+	var tooLarge *SizeTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Errorf("ReadDelimited(buf, nil) = ?, %v; want ?, *SizeTooLargeError", err)
+	}
+}
+
+func TestFrameErrorCleanEOF(t *testing.T) {
+	_, err := ReadDelimited(bytes.NewReader(nil), nil)
+	if err != io.EOF {
+		t.Errorf("ReadDelimited(empty, nil) = ?, %#v; want ?, io.EOF unwrapped", err)
+	}
+}
+
+func TestReaderFrameErrorOffset(t *testing.T) {
+	data := []byte{2, 8, 1, 0, 1, 7}
+	r := NewReader(bytes.NewReader(data))
+	for i := 0; i < 2; i++ {
+		if err := r.Next(new(testdata.Record)); err != nil {
+			t.Fatalf("r.Next(&msg) for record %d = %v, want nil", i, err)
+		}
+	}
+	err := r.Next(new(testdata.Record))
+	var got *FrameError
+	if !errors.As(err, &got) {
+		t.Fatalf("r.Next(&msg) = %v, want *FrameError", err)
+	}
+	if want := int64(4); got.Offset != want {
+		t.Errorf("r.Next(&msg) error offset = %d, want %d", got.Offset, want)
+	}
+	if want := StageUnmarshal; got.Stage != want {
+		t.Errorf("r.Next(&msg) error stage = %v, want %v", got.Stage, want)
+	}
+}
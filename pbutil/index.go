@@ -0,0 +1,158 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// indexMagic begins every encoded Index.
+const indexMagic = "PBIX"
+
+// indexVersion is the version of the Index encoding.
+const indexVersion = 1
+
+var errCorruptIndex = errors.New("pbutil: corrupt record index")
+
+// Index locates the records of a length-delimited stream, permitting random
+// access to them.
+type Index struct {
+	// Offsets holds the position of each record's first byte, in stream
+	// order.
+	Offsets []int64
+	// End is the position just past the final record.
+	End int64
+}
+
+// Len returns the number of records in the index.
+func (x *Index) Len() int { return len(x.Offsets) }
+
+// extent returns the position and length in bytes of record i, including its
+// prefix.
+func (x *Index) extent(i int) (offset, length int64) {
+	end := x.End
+	if i+1 < len(x.Offsets) {
+		end = x.Offsets[i+1]
+	}
+	return x.Offsets[i], end - x.Offsets[i]
+}
+
+// MarshalBinary encodes the index compactly, with offsets stored as varint
+// deltas and the whole protected by a CRC-32C checksum.
+func (x *Index) MarshalBinary() ([]byte, error) {
+	buf := append([]byte(indexMagic), indexVersion)
+	buf = protowire.AppendVarint(buf, uint64(len(x.Offsets)))
+	buf = protowire.AppendVarint(buf, uint64(x.End))
+	var prev int64
+	for _, off := range x.Offsets {
+		if off < prev {
+			return nil, fmt.Errorf("pbutil: index offsets out of order: %d follows %d", off, prev)
+		}
+		buf = protowire.AppendVarint(buf, uint64(off-prev))
+		prev = off
+	}
+	if prev > x.End {
+		return nil, fmt.Errorf("pbutil: index offset %d follows end %d", prev, x.End)
+	}
+	return appendChecksum(buf, buf), nil
+}
+
+// UnmarshalBinary decodes an index produced by MarshalBinary, replacing the
+// contents of x.
+func (x *Index) UnmarshalBinary(data []byte) error {
+	if len(data) < len(indexMagic)+1+checksumLen || !bytes.HasPrefix(data, []byte(indexMagic)) {
+		return errCorruptIndex
+	}
+	body := data[:len(data)-checksumLen]
+	stored := binary.LittleEndian.Uint32(data[len(body):])
+	if computed := crc32.Checksum(body, castagnoli); stored != computed {
+		return fmt.Errorf("%w: %v", errCorruptIndex, &ChecksumError{Stored: stored, Computed: computed})
+	}
+	b := body[len(indexMagic):]
+	if b[0] != indexVersion {
+		return fmt.Errorf("%w: unsupported version %d", errCorruptIndex, b[0])
+	}
+	b = b[1:]
+	count, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return errCorruptIndex
+	}
+	b = b[n:]
+	end, n := protowire.ConsumeVarint(b)
+	if n < 0 || end > 1<<62 {
+		return errCorruptIndex
+	}
+	b = b[n:]
+	// Every offset occupies at least one byte, which bounds the allocation.
+	if count > uint64(len(b)) {
+		return errCorruptIndex
+	}
+	offsets := make([]int64, 0, count)
+	var prev uint64
+	for i := uint64(0); i < count; i++ {
+		delta, n := protowire.ConsumeVarint(b)
+		if n < 0 || delta > end-prev || i > 0 && delta == 0 {
+			return errCorruptIndex
+		}
+		b = b[n:]
+		prev += delta
+		offsets = append(offsets, int64(prev))
+	}
+	if len(b) != 0 || count > 0 && prev == end {
+		return errCorruptIndex
+	}
+	x.Offsets, x.End = offsets, int64(end)
+	return nil
+}
+
+// BuildIndex scans the length-delimited stream r from its current position to
+// its end and returns an Index of its records, so that an IndexedReader can
+// provide random access to a stream that lacks an index footer.  Only the
+// varint prefixes are interpreted; payloads are neither decoded nor retained.
+// Offsets are relative to where scanning began.  The stream is read through
+// an internal buffer, so r need not be buffered.  If a record cannot be
+// framed, BuildIndex returns the *FrameError describing it alongside an Index
+// of the records that precede it.
+func BuildIndex(r io.Reader) (*Index, error) {
+	return ReadDelimitedOptions{}.BuildIndex(r)
+}
+
+// BuildIndex behaves like the package-level BuildIndex function but frames
+// records according to o.
+func (o ReadDelimitedOptions) BuildIndex(r io.Reader) (*Index, error) {
+	rd := o.NewReader(bufio.NewReader(r))
+	idx := new(Index)
+	for {
+		offset := rd.Offset()
+		switch err := rd.Skip(); err {
+		case nil:
+			idx.Offsets = append(idx.Offsets, offset)
+		case io.EOF:
+			idx.End = offset
+			return idx, nil
+		default:
+			idx.End = offset
+			return idx, err
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestIndexMarshalRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		idx  *Index
+	}{
+		{
+			name: "empty",
+			idx:  &Index{},
+		},
+		{
+			name: "single",
+			idx:  &Index{Offsets: []int64{0}, End: 3},
+		},
+		{
+			name: "several",
+			idx:  &Index{Offsets: []int64{0, 3, 4, 300, 70000}, End: 70100},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			enc, err := test.idx.MarshalBinary()
+			if err != nil {
+				t.Fatalf("%v.MarshalBinary() = ?, %v; want ?, nil", test.idx, err)
+			}
+			got := new(Index)
+			if err := got.UnmarshalBinary(enc); err != nil {
+				t.Fatalf("UnmarshalBinary(%v) = %v, want nil", enc, err)
+			}
+			if !cmp.Equal(got, test.idx, cmpopts.EquateEmpty()) {
+				t.Errorf("UnmarshalBinary(%v) decoded %v, want %v", enc, got, test.idx)
+			}
+		})
+	}
+}
+
+func TestIndexMarshalInvalid(t *testing.T) {
+	for _, idx := range []*Index{
+		{Offsets: []int64{3, 0}, End: 5},
+		{Offsets: []int64{0, 10}, End: 5},
+	} {
+		if _, err := idx.MarshalBinary(); err == nil {
+			t.Errorf("%v.MarshalBinary() = ?, nil; want ?, error", idx)
+		}
+	}
+}
+
+func TestIndexUnmarshalCorrupt(t *testing.T) {
+	enc, err := (&Index{Offsets: []int64{0, 3, 4}, End: 10}).MarshalBinary()
+	if err != nil {
+		t.Fatalf("MarshalBinary() = ?, %v; want ?, nil", err)
+	}
+	for _, test := range []struct {
+		name string
+		in   []byte
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "bad magic",
+			in:   append([]byte("XXXX"), enc[4:]...),
+		},
+		{
+			name: "flipped byte",
+			in:   flip(enc, 6),
+		},
+		{
+			name: "truncated",
+			in:   enc[:len(enc)-1],
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if err := new(Index).UnmarshalBinary(test.in); !errors.Is(err, errCorruptIndex) {
+				t.Errorf("UnmarshalBinary(%v) = %v, want %v", test.in, err, errCorruptIndex)
+			}
+		})
+	}
+}
+
+func TestBuildIndex(t *testing.T) {
+	file, data := writeIndexed(t, WriteDelimitedOptions{Checksum: true}, 50)
+	r, err := ReadDelimitedOptions{Checksum: true}.OpenIndexed(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("OpenIndexed(file, %d) = ?, %v; want ?, nil", len(file), err)
+	}
+	want := r.Index()
+
+	records := io.LimitReader(bytes.NewReader(file), want.End)
+	got, err := ReadDelimitedOptions{Checksum: true}.BuildIndex(records)
+	if err != nil {
+		t.Fatalf("BuildIndex(records) = ?, %v; want ?, nil", err)
+	}
+	if !cmp.Equal(got, want) {
+		t.Errorf("BuildIndex(records) = %v, want %v", got, want)
+	}
+
+	sidecar := ReadDelimitedOptions{Checksum: true}.NewIndexedReader(bytes.NewReader(file[:want.End]), got)
+	var msg testdata.Record
+	if err := sidecar.RecordAt(len(data)-1, &msg); err != nil {
+		t.Fatalf("sidecar.RecordAt(%d, &msg) = %v, want nil", len(data)-1, err)
+	}
+	if !cmp.Equal(&msg, data[len(data)-1], protocmp.Transform()) {
+		t.Errorf("sidecar.RecordAt(%d, &msg); msg = %v, want %v", len(data)-1, &msg, data[len(data)-1])
+	}
+}
+
+func TestBuildIndexTruncated(t *testing.T) {
+	data := []byte{2, 8, 1, 0, 5, 8}
+	idx, err := BuildIndex(bytes.NewReader(data))
+	var frameErr *FrameError
+	if !errors.As(err, &frameErr) || frameErr.Offset != 4 {
+		t.Errorf("BuildIndex(%v) = ?, %v; want ?, *FrameError at offset 4", data, err)
+	}
+	if want := (&Index{Offsets: []int64{0, 3}, End: 4}); !cmp.Equal(idx, want) {
+		t.Errorf("BuildIndex(%v) = %v, ?; want %v, ?", data, idx, want)
+	}
+}
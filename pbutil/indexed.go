@@ -0,0 +1,208 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// An indexed record file holds an ordinary length-delimited stream of records
+// followed by a footer that locates them:
+//
+//	...      records
+//	...      Index, as encoded by Index.MarshalBinary
+//	uint64   size in bytes of the encoded Index, little-endian
+//	[4]byte  "PBIX"
+//
+// The records occupy the first Index.End bytes of the file, so the file may
+// still be consumed sequentially by limiting a reader to that prefix.
+
+// indexFooterLen is the size of the fixed portion of the footer.
+const indexFooterLen = 8 + len(indexMagic)
+
+// ErrNoIndex is returned when opening a file that does not end with a record
+// index.
+var ErrNoIndex = errors.New("pbutil: file has no record index")
+
+// IndexedWriter writes an indexed record file.  Callers must call Close to
+// write the index; until then, the output is an ordinary length-delimited
+// stream.
+type IndexedWriter struct {
+	w      *Writer
+	idx    Index
+	closed bool
+}
+
+// NewIndexedWriter returns an IndexedWriter that writes an indexed record file
+// to w.
+func NewIndexedWriter(w io.Writer) *IndexedWriter {
+	return WriteDelimitedOptions{}.NewIndexedWriter(w)
+}
+
+// NewIndexedWriter returns an IndexedWriter that frames records according to
+// o.  The file must be opened with matching ReadDelimitedOptions.
+func (o WriteDelimitedOptions) NewIndexedWriter(w io.Writer) *IndexedWriter {
+	return &IndexedWriter{w: o.NewWriter(w)}
+}
+
+// Write appends m to the file as per Writer.Write and records its position.
+func (w *IndexedWriter) Write(m proto.Message) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	offset := w.w.Offset()
+	if err := w.w.Write(m); err != nil {
+		return err
+	}
+	w.idx.Offsets = append(w.idx.Offsets, offset)
+	return nil
+}
+
+// Close writes the index and footer and flushes all output.  It does not close
+// the underlying io.Writer.
+func (w *IndexedWriter) Close() error {
+	if w.closed {
+		return w.w.Close()
+	}
+	w.closed = true
+	w.idx.End = w.w.Offset()
+	enc, err := w.idx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	enc = binary.LittleEndian.AppendUint64(enc, uint64(len(enc)))
+	enc = append(enc, indexMagic...)
+	if _, err := w.w.bw.Write(enc); err != nil {
+		return err
+	}
+	return w.w.Close()
+}
+
+// Index returns the index of the records written so far.  The caller must not
+// modify it.
+func (w *IndexedWriter) Index() *Index { return &w.idx }
+
+// IndexedReader provides random access to the records of a length-delimited
+// stream by way of an Index.  It is safe for concurrent use if the underlying
+// io.ReaderAt is.
+type IndexedReader struct {
+	opts ReadDelimitedOptions
+	r    io.ReaderAt
+	idx  *Index
+}
+
+// OpenIndexed reads the index from the footer of the indexed record file r,
+// which is size bytes long.  It returns ErrNoIndex if r lacks a footer.
+func OpenIndexed(r io.ReaderAt, size int64) (*IndexedReader, error) {
+	return ReadDelimitedOptions{}.OpenIndexed(r, size)
+}
+
+// OpenIndexed behaves like the package-level OpenIndexed function but reads
+// records according to o.
+func (o ReadDelimitedOptions) OpenIndexed(r io.ReaderAt, size int64) (*IndexedReader, error) {
+	if size < int64(indexFooterLen) {
+		return nil, ErrNoIndex
+	}
+	var footer [indexFooterLen]byte
+	if err := readFullAt(r, footer[:], size-int64(indexFooterLen)); err != nil {
+		return nil, err
+	}
+	if string(footer[8:]) != indexMagic {
+		return nil, ErrNoIndex
+	}
+	encLen := binary.LittleEndian.Uint64(footer[:8])
+	if encLen > uint64(size-int64(indexFooterLen)) {
+		return nil, errCorruptIndex
+	}
+	start := size - int64(indexFooterLen) - int64(encLen)
+	enc := make([]byte, encLen)
+	if err := readFullAt(r, enc, start); err != nil {
+		return nil, err
+	}
+	idx := new(Index)
+	if err := idx.UnmarshalBinary(enc); err != nil {
+		return nil, err
+	}
+	if idx.End != start {
+		return nil, fmt.Errorf("%w: records end at %d, index begins at %d", errCorruptIndex, idx.End, start)
+	}
+	return o.NewIndexedReader(r, idx), nil
+}
+
+// readFullAt fills p from r at off.  io.ReaderAt permits a read that reaches
+// the end of the input to report io.EOF alongside all len(p) bytes, which is
+// not a failure.
+func readFullAt(r io.ReaderAt, p []byte, off int64) error {
+	n, err := r.ReadAt(p, off)
+	if err == io.EOF && n == len(p) {
+		return nil
+	}
+	return err
+}
+
+// NewIndexedReader returns an IndexedReader over the records of r that idx
+// locates.
+func NewIndexedReader(r io.ReaderAt, idx *Index) *IndexedReader {
+	return ReadDelimitedOptions{}.NewIndexedReader(r, idx)
+}
+
+// NewIndexedReader behaves like the package-level NewIndexedReader function
+// but reads records according to o.
+func (o ReadDelimitedOptions) NewIndexedReader(r io.ReaderAt, idx *Index) *IndexedReader {
+	return &IndexedReader{opts: o, r: r, idx: idx}
+}
+
+// Len returns the number of records.
+func (r *IndexedReader) Len() int { return r.idx.Len() }
+
+// Index returns the index in use.  The caller must not modify it.
+func (r *IndexedReader) Index() *Index { return r.idx }
+
+// RecordAt decodes record i into m with a single read of the underlying
+// io.ReaderAt.
+func (r *IndexedReader) RecordAt(i int, m proto.Message) error {
+	payload, err := r.BytesAt(i)
+	if err != nil {
+		return err
+	}
+	return unmarshalFrame(r.idx.Offsets[i], payload, m)
+}
+
+// BytesAt returns the undecoded payload of record i, as NextBytes would.
+func (r *IndexedReader) BytesAt(i int) ([]byte, error) {
+	if i < 0 || i >= r.idx.Len() {
+		return nil, fmt.Errorf("pbutil: record %d out of range [0, %d)", i, r.idx.Len())
+	}
+	offset, length := r.idx.extent(i)
+	frame := make([]byte, length)
+	if err := readFullAt(r.r, frame, offset); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, frameError(offset, StagePayload, 0, err)
+	}
+	cr := countingReader{r: bytes.NewReader(frame)}
+	payload, err := r.opts.readPayload(&cr, new(scratch), offset)
+	if err == nil && int64(cr.n) != length {
+		err = fmt.Errorf("%w: record %d occupies %d bytes, index declares %d", errCorruptIndex, i, cr.n, length)
+	}
+	return payload, err
+}
@@ -0,0 +1,161 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// writeIndexed writes n records to an indexed record file with opts.
+func writeIndexed(t *testing.T, opts WriteDelimitedOptions, n int) ([]byte, []*testdata.Record) {
+	t.Helper()
+	return writeFixture(t, n, opts.NewIndexedWriter, growingRecord)
+}
+
+func TestIndexedRecordAt(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		wopts WriteDelimitedOptions
+		ropts ReadDelimitedOptions
+	}{
+		{
+			name: "plain",
+		},
+		{
+			name:  "checksummed and compressed",
+			wopts: WriteDelimitedOptions{Checksum: true, Compressed: true, Codec: CodecFlate},
+			ropts: ReadDelimitedOptions{Checksum: true, Compressed: true},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			file, data := writeIndexed(t, test.wopts, 200)
+			r, err := test.ropts.OpenIndexed(bytes.NewReader(file), int64(len(file)))
+			if err != nil {
+				t.Fatalf("OpenIndexed(file, %d) = ?, %v; want ?, nil", len(file), err)
+			}
+			if got, want := r.Len(), len(data); got != want {
+				t.Fatalf("r.Len() = %d, want %d", got, want)
+			}
+			for _, i := range []int{199, 0, 57, 58, 1} {
+				var got testdata.Record
+				if err := r.RecordAt(i, &got); err != nil {
+					t.Fatalf("r.RecordAt(%d, &msg) = %v, want nil", i, err)
+				}
+				if !cmp.Equal(&got, data[i], protocmp.Transform()) {
+					t.Errorf("r.RecordAt(%d, &msg); msg = %v, want %v", i, &got, data[i])
+				}
+			}
+			if err := r.RecordAt(len(data), new(testdata.Record)); err == nil {
+				t.Errorf("r.RecordAt(%d, &msg) = nil, want error", len(data))
+			}
+		})
+	}
+}
+
+func TestIndexedSequentialPrefix(t *testing.T) {
+	file, data := writeIndexed(t, WriteDelimitedOptions{}, 10)
+	r, err := OpenIndexed(bytes.NewReader(file), int64(len(file)))
+	if err != nil {
+		t.Fatalf("OpenIndexed(file, %d) = ?, %v; want ?, nil", len(file), err)
+	}
+	seq := NewReader(io.LimitReader(bytes.NewReader(file), r.Index().End))
+	for i := range data {
+		if err := seq.Next(new(testdata.Record)); err != nil {
+			t.Fatalf("seq.Next(&msg) for record %d = %v, want nil", i, err)
+		}
+	}
+	if got, want := seq.Next(new(testdata.Record)), io.EOF; got != want {
+		t.Errorf("seq.Next(&msg) at end of records = %v, want %v", got, want)
+	}
+}
+
+func TestOpenIndexedErrors(t *testing.T) {
+	file, _ := writeIndexed(t, WriteDelimitedOptions{}, 10)
+	var plain bytes.Buffer
+	if _, err := WriteDelimited(&plain, &testdata.Record{Third: proto.String("not an indexed file")}); err != nil {
+		t.Fatalf("WriteDelimited(buf, msg) = ?, %v; want ?, nil", err)
+	}
+	for _, test := range []struct {
+		name string
+		in   []byte
+		want error
+	}{
+		{
+			name: "empty",
+			want: ErrNoIndex,
+		},
+		{
+			name: "plain stream",
+			in:   plain.Bytes(),
+			want: ErrNoIndex,
+		},
+		{
+			name: "corrupt index",
+			in:   flip(file, len(file)-indexFooterLen-6),
+			want: errCorruptIndex,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := OpenIndexed(bytes.NewReader(test.in), int64(len(test.in)))
+			if !errors.Is(err, test.want) {
+				t.Errorf("OpenIndexed(%v) = ?, %v; want ?, %v", test.in, err, test.want)
+			}
+		})
+	}
+}
+
+// eofAtEnd is an io.ReaderAt that reports io.EOF alongside any read reaching
+// the end of its data, as io.ReaderAt permits.
+type eofAtEnd struct{ *bytes.Reader }
+
+func (r eofAtEnd) ReadAt(p []byte, off int64) (int, error) {
+	n, err := r.Reader.ReadAt(p, off)
+	if err == nil && off+int64(n) == r.Size() {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func TestIndexedReaderAtEOF(t *testing.T) {
+	file, data := writeIndexed(t, WriteDelimitedOptions{}, 10)
+	r, err := OpenIndexed(eofAtEnd{bytes.NewReader(file)}, int64(len(file)))
+	if err != nil {
+		t.Fatalf("OpenIndexed(file, %d) = ?, %v; want ?, nil", len(file), err)
+	}
+
+	// Without a footer, the last record ends the input.
+	stream := file[:r.Index().End]
+	idx, err := BuildIndex(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatalf("BuildIndex(stream) = ?, %v; want ?, nil", err)
+	}
+	r = NewIndexedReader(eofAtEnd{bytes.NewReader(stream)}, idx)
+	last := len(data) - 1
+	var got testdata.Record
+	if err := r.RecordAt(last, &got); err != nil {
+		t.Fatalf("r.RecordAt(%d, &msg) = %v, want nil", last, err)
+	}
+	if !cmp.Equal(&got, data[last], protocmp.Transform()) {
+		t.Errorf("r.RecordAt(%d, &msg); msg = %v, want %v", last, &got, data[last])
+	}
+}
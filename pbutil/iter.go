@@ -0,0 +1,74 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package pbutil
+
+import (
+	"io"
+	"iter"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// All returns an iterator over the records of the length-delimited stream r,
+// decoding each into a fresh message obtained from newT.  Iteration stops
+// silently when the stream ends cleanly on a record boundary.  Any other
+// error, including a stream truncated partway through a record, is yielded
+// once alongside the zero value of T, after which iteration stops.
+//
+//	for m, err := range pbutil.All(r, func() *mypb.Event { return new(mypb.Event) }) {
+//		if err != nil {
+//			return err
+//		}
+//		// Use m.
+//	}
+func All[T proto.Message](r io.Reader, newT func() T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		rd := NewReader(r)
+		for {
+			m := newT()
+			if !yieldNext(rd, m, yield) {
+				return
+			}
+		}
+	}
+}
+
+// AllInto is like All but decodes every record into m, which is yielded at
+// each step.  Its contents are only valid until the next iteration, so callers
+// must clone any record they wish to retain.
+func AllInto[T proto.Message](r io.Reader, m T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		rd := NewReader(r)
+		for yieldNext(rd, m, yield) {
+		}
+	}
+}
+
+// yieldNext decodes the next record of rd into m and passes the outcome to
+// yield.  It reports whether iteration should continue.
+func yieldNext[T proto.Message](rd *Reader, m T, yield func(T, error) bool) bool {
+	switch err := rd.Next(m); err {
+	case nil:
+		return yield(m, nil)
+	case io.EOF:
+		return false
+	default:
+		var zero T
+		yield(zero, err)
+		return false
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func newRecord() *testdata.Record { return new(testdata.Record) }
+
+func TestAll(t *testing.T) {
+	data := []*testdata.Record{
+		{First: proto.Uint64(1)},
+		{},
+		{Third: proto.String("third")},
+	}
+	var buf bytes.Buffer
+	for i, msg := range data {
+		if _, err := WriteDelimited(&buf, msg); err != nil {
+			t.Fatalf("WriteDelimited(buf, data[%d]) = ?, %v; want ?, nil", i, err)
+		}
+	}
+	var got []*testdata.Record
+	for m, err := range All(&buf, newRecord) {
+		if err != nil {
+			t.Fatalf("All(buf, newRecord) yielded error %v", err)
+		}
+		got = append(got, m)
+	}
+	if !cmp.Equal(got, data, protocmp.Transform()) {
+		t.Errorf("All(buf, newRecord) yielded %v, want %v", got, data)
+	}
+}
+
+func TestAllTruncated(t *testing.T) {
+	data := []byte{2, 8, 1, 5, 8}
+	var msgs int
+	var errs []error
+	for m, err := range All(bytes.NewReader(data), newRecord) {
+		if err != nil {
+			if m != nil {
+				t.Errorf("All(%v, newRecord) yielded %v alongside error, want nil", data, m)
+			}
+			errs = append(errs, err)
+			continue
+		}
+		msgs++
+	}
+	if got, want := msgs, 1; got != want {
+		t.Errorf("All(%v, newRecord) yielded %d messages, want %d", data, got, want)
+	}
+	if got, want := len(errs), 1; got != want {
+		t.Fatalf("All(%v, newRecord) yielded %d errors, want %d", data, got, want)
+	}
+	if got, want := errs[0], io.ErrUnexpectedEOF; !errors.Is(got, want) {
+		t.Errorf("All(%v, newRecord) yielded error %v, want %v", data, got, want)
+	}
+}
+
+func TestAllBreak(t *testing.T) {
+	data := []byte{2, 8, 1, 2, 8, 2}
+	r := bytes.NewReader(data)
+	for range All(r, newRecord) {
+		break
+	}
+	if got, want := r.Len(), 3; got != want {
+		t.Errorf("after break, %d bytes remain unread, want %d", got, want)
+	}
+}
+
+func TestAllInto(t *testing.T) {
+	data := []byte{2, 8, 1, 2, 8, 2}
+	var msg testdata.Record
+	var got []uint64
+	for m, err := range AllInto(bytes.NewReader(data), &msg) {
+		if err != nil {
+			t.Fatalf("AllInto(%v, &msg) yielded error %v", data, err)
+		}
+		if m != &msg {
+			t.Errorf("AllInto(%v, &msg) yielded %p, want %p", data, m, &msg)
+		}
+		got = append(got, m.GetFirst())
+	}
+	if want := []uint64{1, 2}; !cmp.Equal(got, want) {
+		t.Errorf("AllInto(%v, &msg) decoded %v, want %v", data, got, want)
+	}
+}
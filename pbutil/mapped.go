@@ -0,0 +1,212 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var errMappedReaderClosed = errors.New("pbutil: read from closed MappedReader")
+
+// MappedReader decodes the records of a length-delimited file that it maps into
+// memory, avoiding the copies that reading through io.Reader makes.  On Linux,
+// the file is mapped read-only with mmap, and the payloads that NextBytes
+// returns point directly into the mapping.  Where mmap is unavailable or fails,
+// MappedReader falls back to reading each record with ReadAt into a freshly
+// allocated buffer, preserving the same lifetime rules at the cost of a copy.
+// Mapped reports which strategy is in use.
+//
+// Payloads returned by NextBytes remain valid until Close is called, after
+// which accessing a payload that points into the mapping faults.  They must not
+// be modified, as the mapping is read-only; callers that need a payload beyond
+// Close must copy it.  Messages decoded by Next do not retain references to
+// the mapping and remain valid indefinitely.  Payloads of records read under
+// ReadDelimitedOptions.Compressed are decompressed into fresh buffers and so
+// are never zero-copy.
+//
+// A MappedReader reads the file as it was sized when the reader was created.
+// The file must not be truncated while mapped, as accessing pages beyond its
+// new end faults.
+type MappedReader struct {
+	opts   ReadDelimitedOptions
+	f      *os.File
+	owned  bool
+	data   []byte
+	mapped bool
+	size   int64
+	offset int64
+	index  int64
+	closed bool
+}
+
+// OpenMapped opens the named file and returns a MappedReader that decodes its
+// records with the default options.  Close closes the file.
+func OpenMapped(name string) (*MappedReader, error) {
+	return ReadDelimitedOptions{}.OpenMapped(name)
+}
+
+// OpenMapped opens the named file and returns a MappedReader that decodes its
+// records according to o.  Close closes the file.
+func (o ReadDelimitedOptions) OpenMapped(name string) (*MappedReader, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	r, err := o.newMappedReader(f, true, true)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewMappedReader returns a MappedReader that decodes the records of f with
+// the default options.  The caller retains ownership of f, which must remain
+// open until Close returns.
+func NewMappedReader(f *os.File) (*MappedReader, error) {
+	return ReadDelimitedOptions{}.NewMappedReader(f)
+}
+
+// NewMappedReader returns a MappedReader that decodes the records of f
+// according to o.  The caller retains ownership of f, which must remain open
+// until Close returns.
+func (o ReadDelimitedOptions) NewMappedReader(f *os.File) (*MappedReader, error) {
+	return o.newMappedReader(f, false, true)
+}
+
+// newMappedReader maps f if tryMmap is set, falling back to ReadAt if mapping
+// fails.  An empty file is never mapped, since mmap rejects a zero length.
+func (o ReadDelimitedOptions) newMappedReader(f *os.File, owned, tryMmap bool) (*MappedReader, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	r := &MappedReader{opts: o, f: f, owned: owned, size: fi.Size()}
+	if size := int(r.size); tryMmap && size > 0 && int64(size) == r.size && fi.Mode().IsRegular() {
+		if data, err := mmap(f, size); err == nil {
+			r.data, r.mapped = data, true
+		}
+	}
+	return r, nil
+}
+
+// Mapped reports whether the file is memory mapped, as opposed to being read
+// through ReadAt.
+func (r *MappedReader) Mapped() bool { return r.mapped }
+
+// Next decodes the next record into m.  It returns io.EOF, unwrapped, once the
+// file ends cleanly on a record boundary.  Errors are reported as they are by
+// Reader.Next.
+func (r *MappedReader) Next(m proto.Message) error {
+	offset := r.offset
+	payload, err := r.NextBytes()
+	if err != nil {
+		return err
+	}
+	return unmarshalFrame(offset, payload, m)
+}
+
+// NextBytes returns the undecoded payload of the next record, subject to the
+// lifetime rules described on MappedReader.
+func (r *MappedReader) NextBytes() ([]byte, error) {
+	if r.closed {
+		return nil, errMappedReaderClosed
+	}
+	var payload []byte
+	var n int
+	var err error
+	if r.mapped {
+		payload, n, err = r.opts.consumePayload(r.data[r.offset:], r.offset)
+	} else {
+		payload, n, err = r.readAt()
+	}
+	r.offset += int64(n)
+	if err == nil || isDiscarded(err) {
+		r.index++
+	}
+	return payload, err
+}
+
+// readAt reads the next record with ReadAt, returning what consumePayload
+// would for the same bytes.  The prefix is read speculatively along with the
+// start of the payload, so a record takes one ReadAt if it fits within that
+// read and two otherwise.
+func (r *MappedReader) readAt() (payload []byte, n int, err error) {
+	rest := r.size - r.offset
+	var arr [binary.MaxVarintLen64]byte
+	head := arr[:]
+	if rest < int64(len(head)) {
+		head = head[:rest]
+	}
+	if err := readFullAt(r.f, head, r.offset); err != nil {
+		return nil, 0, frameError(r.offset, StageHeader, 0, err)
+	}
+	size, hn, err := consumeHeader(head)
+	if err != nil {
+		return nil, hn, frameError(r.offset, StageHeader, 0, err)
+	}
+	avail := uint64(rest) - uint64(hn)
+	if skipped, err := r.opts.rejectOversized(size, avail, r.offset); err != nil {
+		return nil, hn + int(skipped), err
+	}
+	// The size is within the limit, so the frame's length cannot overflow.
+	frameLen := uint64(hn) + size + r.opts.trailerLen()
+	if frameLen > uint64(rest) {
+		frameLen = uint64(rest)
+	}
+	frame := make([]byte, frameLen)
+	if copied := copy(frame, head); copied < len(frame) {
+		if err := readFullAt(r.f, frame[copied:], r.offset+int64(copied)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, copied, frameError(r.offset, StagePayload, size, err)
+		}
+	}
+	return r.opts.consumePayload(frame, r.offset)
+}
+
+// Offset returns the number of bytes of the file consumed so far.
+func (r *MappedReader) Offset() int64 { return r.offset }
+
+// Index returns the zero-based index of the next record to be read, counted as
+// Reader.Index counts it.
+func (r *MappedReader) Index() int64 { return r.index }
+
+// Close releases the mapping, invalidating every payload that points into it,
+// and closes the file if the MappedReader opened it.  Calling Close more than
+// once returns nil after the first.
+func (r *MappedReader) Close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	var err error
+	if r.data != nil {
+		err = munmap(r.data)
+		r.data = nil
+	}
+	if r.owned {
+		if cerr := r.f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
@@ -0,0 +1,218 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+// writeFile writes data to a new file in a temporary directory and returns its
+// name.
+func writeFile(t *testing.T, data []byte) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "records")
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+// openMapped opens name as a MappedReader, memory mapped or not.
+func openMapped(t *testing.T, opts ReadDelimitedOptions, name string, tryMmap bool) *MappedReader {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := opts.newMappedReader(f, true, tryMmap)
+	if err != nil {
+		f.Close()
+		t.Fatalf("newMappedReader(%v) = ?, %v; want ?, nil", name, err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestMappedReader(t *testing.T) {
+	for _, tryMmap := range []bool{true, false} {
+		for _, test := range sliceOptions {
+			t.Run(fmt.Sprintf("%v/mmap=%v", test.name, tryMmap), func(t *testing.T) {
+				stream, data := recordStream(t, test.write, 50)
+				r := openMapped(t, test.read, writeFile(t, stream), tryMmap)
+				if want := tryMmap && runtime.GOOS == "linux"; r.Mapped() != want {
+					t.Errorf("r.Mapped() = %v, want %v", r.Mapped(), want)
+				}
+				var got []*testdata.Record
+				for {
+					msg := new(testdata.Record)
+					err := r.Next(msg)
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatalf("r.Next(&msg) for record %d = %v, want nil", len(got), err)
+					}
+					got = append(got, msg)
+				}
+				if !cmp.Equal(got, data, protocmp.Transform()) {
+					t.Errorf("r.Next decoded %v, want %v", got, data)
+				}
+				if r.Offset() != int64(len(stream)) || r.Index() != int64(len(data)) {
+					t.Errorf("r.Offset(), r.Index() = %d, %d; want %d, %d", r.Offset(), r.Index(), len(stream), len(data))
+				}
+				if err := r.Next(new(testdata.Record)); err != io.EOF {
+					t.Errorf("r.Next(&msg) at end = %v, want io.EOF", err)
+				}
+			})
+		}
+	}
+}
+
+func TestMappedReaderZeroCopy(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("memory mapping is only implemented on Linux")
+	}
+	stream, _ := recordStream(t, WriteDelimitedOptions{}, 10)
+	r := openMapped(t, ReadDelimitedOptions{}, writeFile(t, stream), true)
+	start := uintptr(unsafe.Pointer(&r.data[0]))
+	for i := 0; i < 10; i++ {
+		offset := r.Offset()
+		payload, err := r.NextBytes()
+		if err != nil {
+			t.Fatalf("r.NextBytes() = ?, %v; want ?, nil", err)
+		}
+		if len(payload) == 0 {
+			continue
+		}
+		// The payload follows its one-byte length prefix in the mapping.
+		if got, want := uintptr(unsafe.Pointer(&payload[0]))-start, uintptr(offset+1); got != want {
+			t.Errorf("record %d payload at mapping offset %d, want %d", i, got, want)
+		}
+	}
+}
+
+func TestMappedReaderMatchesReader(t *testing.T) {
+	stream, _ := recordStream(t, WriteDelimitedOptions{}, 5)
+	large, _ := recordStream(t, WriteDelimitedOptions{}, 40)
+	checksummed, _ := recordStream(t, WriteDelimitedOptions{Checksum: true}, 20)
+	for _, test := range []struct {
+		name  string
+		opts  ReadDelimitedOptions
+		input []byte
+	}{
+		{name: "empty"},
+		{name: "truncated", input: stream[:len(stream)-1]},
+		{name: "truncated header", input: append(append([]byte(nil), stream...), 0x80, 0x80)},
+		{name: "records longer than a prefix", input: large},
+		{name: "records longer than a prefix truncated", input: large[:len(large)-20]},
+		{name: "checksummed", opts: ReadDelimitedOptions{Checksum: true}, input: checksummed},
+		{name: "checksum truncated", opts: ReadDelimitedOptions{Checksum: true}, input: checksummed[:len(checksummed)-2]},
+		{name: "oversized and truncated", opts: ReadDelimitedOptions{MaxSize: 3, DiscardOversized: true}, input: large[:len(large)-20]},
+		{name: "invalid payload", input: append(append([]byte(nil), stream...), 2, 0xff, 0xff)},
+		{name: "oversized", opts: ReadDelimitedOptions{MaxSize: 3}, input: stream},
+		{name: "oversized and discarded", opts: ReadDelimitedOptions{MaxSize: 3, DiscardOversized: true}, input: stream},
+	} {
+		for _, tryMmap := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%v/mmap=%v", test.name, tryMmap), func(t *testing.T) {
+				want := test.opts.NewReader(bytes.NewReader(test.input))
+				got := openMapped(t, test.opts, writeFile(t, test.input), tryMmap)
+				for i := 0; ; i++ {
+					var wantMsg, gotMsg testdata.Record
+					wantErr := want.Next(&wantMsg)
+					gotErr := got.Next(&gotMsg)
+					if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) {
+						t.Fatalf("record %d: r.Next(&msg) = %v, want %v", i, gotErr, wantErr)
+					}
+					if !cmp.Equal(&gotMsg, &wantMsg, protocmp.Transform()) {
+						t.Errorf("record %d: r.Next(&msg); msg = %v, want %v", i, &gotMsg, &wantMsg)
+					}
+					if got.Offset() != want.Offset() || got.Index() != want.Index() {
+						t.Errorf("record %d: r.Offset(), r.Index() = %d, %d; want %d, %d", i, got.Offset(), got.Index(), want.Offset(), want.Index())
+					}
+					if wantErr != nil && !isDiscarded(wantErr) {
+						break
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestMappedReaderClose(t *testing.T) {
+	stream, _ := recordStream(t, WriteDelimitedOptions{}, 3)
+	name := writeFile(t, stream)
+
+	r, err := OpenMapped(name)
+	if err != nil {
+		t.Fatalf("OpenMapped(%v) = ?, %v; want ?, nil", name, err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("r.Close() = %v, want nil", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("second r.Close() = %v, want nil", err)
+	}
+	if _, err := r.NextBytes(); err != errMappedReaderClosed {
+		t.Errorf("r.NextBytes() after Close = ?, %v; want ?, %v", err, errMappedReaderClosed)
+	}
+
+	// A MappedReader leaves a file it did not open open.
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, err = NewMappedReader(f)
+	if err != nil {
+		t.Fatalf("NewMappedReader(f) = ?, %v; want ?, nil", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("r.Close() = %v, want nil", err)
+	}
+	got, err := io.ReadAll(f)
+	if err != nil || !cmp.Equal(got, stream, cmpopts.EquateEmpty()) {
+		t.Errorf("io.ReadAll(f) after r.Close() = %v, %v; want %v, nil", got, err, stream)
+	}
+}
+
+func TestMappedReaderEmpty(t *testing.T) {
+	r := openMapped(t, ReadDelimitedOptions{}, writeFile(t, nil), true)
+	if r.Mapped() {
+		t.Errorf("r.Mapped() for empty file = true, want false")
+	}
+	if err := r.Next(new(testdata.Record)); err != io.EOF {
+		t.Errorf("r.Next(&msg) for empty file = %v, want io.EOF", err)
+	}
+}
+
+func TestOpenMappedMissing(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "missing")
+	if _, err := OpenMapped(name); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenMapped(%v) = ?, %v; want ?, %v", name, err, os.ErrNotExist)
+	}
+}
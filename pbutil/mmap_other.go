@@ -0,0 +1,35 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux
+
+package pbutil
+
+import (
+	"errors"
+	"os"
+)
+
+var errMmapUnsupported = errors.New("pbutil: memory mapping is not supported on this platform")
+
+// mmap reports that memory mapping is unavailable, so MappedReader falls back
+// to reading through io.ReaderAt.
+func mmap(f *os.File, size int) ([]byte, error) {
+	return nil, errMmapUnsupported
+}
+
+// munmap is never called, since mmap never succeeds.
+func munmap(data []byte) error {
+	return errMmapUnsupported
+}
@@ -0,0 +1,343 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var errParallelClosed = errors.New("pbutil: use of closed parallel reader or writer")
+
+// ParallelOptions configures the concurrency of ParallelReader and
+// ParallelWriter.
+type ParallelOptions struct {
+	// Workers is the number of goroutines that decode or encode records.
+	// Zero or less selects runtime.GOMAXPROCS(0).
+	Workers int
+
+	// InFlight bounds the number of records that have been read but not yet
+	// returned by Next, or submitted but not yet written, and with it the
+	// memory held by the pipeline.  Zero or less selects four per worker.
+	InFlight int
+}
+
+// workers returns the effective number of workers.
+func (o ParallelOptions) workers() int {
+	if o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+// inFlight returns the effective bound on records in flight.
+func (o ParallelOptions) inFlight() int {
+	if o.InFlight <= 0 {
+		return 4 * o.workers()
+	}
+	return o.InFlight
+}
+
+// decodeJob is a record passing through a ParallelReader.
+type decodeJob struct {
+	offset  int64
+	payload []byte
+	m       proto.Message
+	err     error
+	done    chan struct{}
+}
+
+// ParallelReader decodes the records of a stream using several goroutines,
+// returning them in stream order.  One goroutine reads records sequentially
+// through a Reader, which determines their framing, while a pool of workers
+// unmarshals them.  While the ParallelReader is open, it owns the Reader;
+// callers must not use the Reader until Close returns.
+type ParallelReader struct {
+	slots   chan struct{}
+	ordered chan *decodeJob
+	free    chan []byte
+	quit    chan struct{}
+	wg      sync.WaitGroup
+	once    sync.Once
+	err     error
+}
+
+// NewParallelReader returns a ParallelReader that decodes records from r into
+// messages allocated by newMessage, with the default concurrency.
+func NewParallelReader(r *Reader, newMessage func() proto.Message) *ParallelReader {
+	return ParallelOptions{}.NewParallelReader(r, newMessage)
+}
+
+// NewParallelReader returns a ParallelReader with the concurrency given by o.
+// newMessage is called concurrently from the workers.
+func (o ParallelOptions) NewParallelReader(r *Reader, newMessage func() proto.Message) *ParallelReader {
+	inFlight := o.inFlight()
+	pr := &ParallelReader{
+		slots:   make(chan struct{}, inFlight),
+		ordered: make(chan *decodeJob, inFlight),
+		free:    make(chan []byte, inFlight+1),
+		quit:    make(chan struct{}),
+	}
+	work := make(chan *decodeJob, inFlight)
+	pr.wg.Add(1)
+	go func() {
+		defer pr.wg.Done()
+		defer close(work)
+		pr.read(r, work)
+	}()
+	for i := o.workers(); i > 0; i-- {
+		pr.wg.Add(1)
+		go func() {
+			defer pr.wg.Done()
+			for job := range work {
+				job.m = newMessage()
+				job.err = unmarshalFrame(job.offset, job.payload, job.m)
+				close(job.done)
+			}
+		}()
+	}
+	return pr
+}
+
+// read reads records from r, queuing them for delivery in order and for
+// decoding by the workers, until the stream ends or the ParallelReader is
+// closed.  It takes one of pr.slots before reading each record, which Next
+// returns once it is done with the record, so that no more than InFlight
+// records are held at once.
+func (pr *ParallelReader) read(r *Reader, work chan<- *decodeJob) {
+	for {
+		select {
+		case pr.slots <- struct{}{}:
+		case <-pr.quit:
+			return
+		}
+		offset := r.Offset()
+		payload, err := r.NextBytes()
+		job := &decodeJob{offset: offset, err: err, done: make(chan struct{})}
+		if err == nil {
+			var buf []byte
+			select {
+			case buf = <-pr.free:
+			default:
+			}
+			job.payload = append(buf[:0], payload...)
+		} else {
+			close(job.done)
+		}
+		// Holding a slot guarantees room in pr.ordered.
+		pr.ordered <- job
+		if err != nil {
+			return
+		}
+		// work has the capacity of ordered, so this never blocks for long.
+		select {
+		case work <- job:
+		case <-pr.quit:
+			return
+		}
+	}
+}
+
+// Next returns the next record of the stream, decoded into a message from
+// newMessage.  It returns io.EOF, unwrapped, once the stream ends cleanly.
+// Errors are reported in stream order, after every record that precedes the
+// failing one, and are sticky: once Next returns an error, it returns that
+// error on every later call and the pipeline stops.
+func (pr *ParallelReader) Next() (proto.Message, error) {
+	if pr.err != nil {
+		return nil, pr.err
+	}
+	job := <-pr.ordered
+	<-job.done
+	<-pr.slots
+	if job.payload != nil {
+		select {
+		case pr.free <- job.payload:
+		default:
+		}
+	}
+	if job.err != nil {
+		pr.err = job.err
+		pr.shutdown()
+		return nil, job.err
+	}
+	return job.m, nil
+}
+
+// Close stops the pipeline and releases its goroutines, waiting for any read
+// already in progress on the underlying stream to finish.  It is safe to call
+// Close more than once, and necessary only when abandoning a stream before
+// Next returns an error.
+func (pr *ParallelReader) Close() error {
+	pr.shutdown()
+	if pr.err == nil {
+		pr.err = errParallelClosed
+	}
+	return nil
+}
+
+// shutdown stops the pipeline's goroutines and waits for them to exit.
+func (pr *ParallelReader) shutdown() {
+	pr.once.Do(func() {
+		close(pr.quit)
+		pr.wg.Wait()
+	})
+}
+
+// encodeJob is a message passing through a ParallelWriter.
+type encodeJob struct {
+	m       proto.Message
+	payload []byte
+	err     error
+	done    chan struct{}
+}
+
+// ParallelWriter encodes records using several goroutines, committing them to
+// a Writer in the order they were submitted.  A pool of workers marshals the
+// submitted messages while one goroutine writes their frames sequentially
+// through the Writer, which determines their framing.  While the
+// ParallelWriter is open, it owns the Writer; callers must not use the Writer
+// until Close returns.
+type ParallelWriter struct {
+	w       *Writer
+	start   int64
+	slots   chan struct{}
+	work    chan *encodeJob
+	ordered chan *encodeJob
+	free    chan []byte
+	failed  chan struct{}
+	wg      sync.WaitGroup
+	err     error
+	closed  bool
+}
+
+// NewParallelWriter returns a ParallelWriter that writes records to w with the
+// default concurrency.
+func NewParallelWriter(w *Writer) *ParallelWriter {
+	return ParallelOptions{}.NewParallelWriter(w)
+}
+
+// NewParallelWriter returns a ParallelWriter with the concurrency given by o.
+func (o ParallelOptions) NewParallelWriter(w *Writer) *ParallelWriter {
+	inFlight := o.inFlight()
+	pw := &ParallelWriter{
+		w:       w,
+		start:   w.Offset(),
+		slots:   make(chan struct{}, inFlight),
+		work:    make(chan *encodeJob, inFlight),
+		ordered: make(chan *encodeJob, inFlight),
+		free:    make(chan []byte, inFlight+1),
+		failed:  make(chan struct{}),
+	}
+	pw.wg.Add(1)
+	go func() {
+		defer pw.wg.Done()
+		pw.commit()
+	}()
+	for i := o.workers(); i > 0; i-- {
+		pw.wg.Add(1)
+		go func() {
+			defer pw.wg.Done()
+			for job := range pw.work {
+				var buf []byte
+				select {
+				case buf = <-pw.free:
+				default:
+				}
+				job.payload, job.err = proto.MarshalOptions{}.MarshalAppend(buf[:0], job.m)
+				job.m = nil
+				close(job.done)
+			}
+		}()
+	}
+	return pw
+}
+
+// commit writes the marshaled records to the Writer in submission order,
+// returning each record's slot once it is written.  After the first error, it
+// records the error and discards the remaining records.
+func (pw *ParallelWriter) commit() {
+	for job := range pw.ordered {
+		<-job.done
+		if pw.err == nil {
+			err := job.err
+			if err == nil {
+				err = pw.w.WriteBytes(job.payload)
+			}
+			if err != nil {
+				pw.err = err
+				close(pw.failed)
+			}
+		}
+		select {
+		case pw.free <- job.payload:
+		default:
+		}
+		<-pw.slots
+	}
+}
+
+// Submit queues m to be written as the next record.  It blocks while the
+// number of records in flight is at the bound given by ParallelOptions, and
+// otherwise returns before m is marshaled, so m must not be modified until
+// Close returns.  Errors, whether from marshaling or from the Writer, are
+// sticky: once one occurs, Submit returns it and the remaining records are
+// discarded.  Submit is not safe for concurrent use.
+func (pw *ParallelWriter) Submit(m proto.Message) error {
+	if pw.closed {
+		return errParallelClosed
+	}
+	select {
+	case <-pw.failed:
+		return pw.err
+	default:
+	}
+	select {
+	case pw.slots <- struct{}{}:
+	case <-pw.failed:
+		return pw.err
+	}
+	// Holding a slot guarantees room in pw.ordered.
+	job := &encodeJob{m: m, done: make(chan struct{})}
+	pw.ordered <- job
+	// The workers never block, so this waits at most for one of them to
+	// take a queued record.
+	pw.work <- job
+	return nil
+}
+
+// Close waits for the submitted records to be written, flushes the Writer, and
+// releases the pipeline's goroutines.  After an error, the records that
+// preceded the failing one are flushed and the rest are discarded.  It returns
+// the number of bytes the ParallelWriter wrote through the Writer and the first
+// error encountered.  It does not close the Writer.  Calling Close more than
+// once returns the same results.
+func (pw *ParallelWriter) Close() (int64, error) {
+	if !pw.closed {
+		pw.closed = true
+		close(pw.ordered)
+		close(pw.work)
+		pw.wg.Wait()
+		// The records committed before a marshaling error are still
+		// flushed, leaving the stream well-formed.
+		if err := pw.w.Flush(); pw.err == nil {
+			pw.err = err
+		}
+	}
+	return pw.w.Offset() - pw.start, pw.err
+}
@@ -0,0 +1,362 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func newRecordMessage() proto.Message { return new(testdata.Record) }
+
+func TestParallelReader(t *testing.T) {
+	stream, data := recordStream(t, WriteDelimitedOptions{}, 500)
+	for _, test := range []struct {
+		name string
+		opts ParallelOptions
+	}{
+		{name: "default"},
+		{name: "one worker", opts: ParallelOptions{Workers: 1, InFlight: 1}},
+		{name: "many workers", opts: ParallelOptions{Workers: 16, InFlight: 3}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pr := test.opts.NewParallelReader(NewReader(bytes.NewReader(stream)), newRecordMessage)
+			defer pr.Close()
+			for i, want := range data {
+				got, err := pr.Next()
+				if err != nil {
+					t.Fatalf("pr.Next() for record %d = ?, %v; want ?, nil", i, err)
+				}
+				if !cmp.Equal(got, want, protocmp.Transform()) {
+					t.Fatalf("pr.Next() for record %d = %v, want %v", i, got, want)
+				}
+			}
+			for i := 0; i < 2; i++ {
+				if got, err := pr.Next(); err != io.EOF {
+					t.Errorf("pr.Next() at end = %v, %v; want nil, io.EOF", got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestParallelReaderErrors(t *testing.T) {
+	stream, data := recordStream(t, WriteDelimitedOptions{}, 20)
+	var corrupt bytes.Buffer
+	corrupt.Write(stream)
+	corrupt.Write([]byte{2, 0xff, 0xff}) // An invalid payload.
+	corrupt.Write(stream)
+	for _, test := range []struct {
+		name   string
+		input  []byte
+		err    error
+		offset int64
+		stage  Stage
+	}{
+		{
+			name:   "unmarshal",
+			input:  corrupt.Bytes(),
+			err:    errAny,
+			offset: int64(len(stream)),
+			stage:  StageUnmarshal,
+		},
+		{
+			name:   "truncated",
+			input:  append(append([]byte(nil), stream...), 5, 8),
+			err:    io.ErrUnexpectedEOF,
+			offset: int64(len(stream)),
+			stage:  StagePayload,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pr := ParallelOptions{Workers: 4, InFlight: 4}.NewParallelReader(NewReader(bytes.NewReader(test.input)), newRecordMessage)
+			defer pr.Close()
+			for i, want := range data {
+				got, err := pr.Next()
+				if err != nil || !cmp.Equal(got, want, protocmp.Transform()) {
+					t.Fatalf("pr.Next() for record %d = %v, %v; want %v, nil", i, got, err, want)
+				}
+			}
+			_, err := pr.Next()
+			if !matchErr(err, test.err) {
+				t.Fatalf("pr.Next() = ?, %v; want ?, %v", err, test.err)
+			}
+			var fe *FrameError
+			if !errors.As(err, &fe) || fe.Offset != test.offset || fe.Stage != test.stage {
+				t.Errorf("pr.Next() = ?, %v; want *FrameError at offset %d in %v", err, test.offset, test.stage)
+			}
+			if _, again := pr.Next(); again != err {
+				t.Errorf("pr.Next() after error = ?, %v; want ?, %v", again, err)
+			}
+		})
+	}
+}
+
+// countingSource is an io.Reader that counts the bytes read from it.
+type countingSource struct {
+	r io.Reader
+	n atomic.Int64
+}
+
+func (c *countingSource) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+func TestParallelReaderBoundsInFlight(t *testing.T) {
+	var buf bytes.Buffer
+	msg := &testdata.Record{First: proto.Uint64(1)}
+	for i := 0; i < 100; i++ {
+		if _, err := WriteDelimited(&buf, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	frameLen := int64(buf.Len() / 100)
+	src := &countingSource{r: &buf}
+	const inFlight = 3
+	pr := ParallelOptions{Workers: 2, InFlight: inFlight}.NewParallelReader(NewReader(src), newRecordMessage)
+	defer pr.Close()
+	time.Sleep(20 * time.Millisecond)
+	if got, limit := src.n.Load(), inFlight*frameLen; got > limit {
+		t.Errorf("before any call to Next, read %d bytes, want at most %d", got, limit)
+	}
+}
+
+func TestParallelReaderClose(t *testing.T) {
+	stream, _ := recordStream(t, WriteDelimitedOptions{}, 100)
+	pr := NewParallelReader(NewReader(bytes.NewReader(stream)), newRecordMessage)
+	if _, err := pr.Next(); err != nil {
+		t.Fatalf("pr.Next() = ?, %v; want ?, nil", err)
+	}
+	if err := pr.Close(); err != nil {
+		t.Errorf("pr.Close() = %v, want nil", err)
+	}
+	if err := pr.Close(); err != nil {
+		t.Errorf("second pr.Close() = %v, want nil", err)
+	}
+	if _, err := pr.Next(); err != errParallelClosed {
+		t.Errorf("pr.Next() after Close = ?, %v; want ?, %v", err, errParallelClosed)
+	}
+}
+
+func TestParallelWriter(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		opts ParallelOptions
+		wopt WriteDelimitedOptions
+	}{
+		{name: "default"},
+		{name: "one worker", opts: ParallelOptions{Workers: 1, InFlight: 1}},
+		{name: "many workers", opts: ParallelOptions{Workers: 16, InFlight: 3}},
+		{name: "checksummed and compressed", wopt: WriteDelimitedOptions{Checksum: true, Compressed: true, Codec: CodecGzip}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			want, data := recordStream(t, test.wopt, 500)
+			var got bytes.Buffer
+			pw := test.opts.NewParallelWriter(test.wopt.NewWriter(&got))
+			for i, msg := range data {
+				if err := pw.Submit(msg); err != nil {
+					t.Fatalf("pw.Submit(record %d) = %v, want nil", i, err)
+				}
+			}
+			n, err := pw.Close()
+			if n != int64(len(want)) || err != nil {
+				t.Fatalf("pw.Close() = %d, %v; want %d, nil", n, err, len(want))
+			}
+			if !bytes.Equal(got.Bytes(), want) {
+				t.Errorf("ParallelWriter wrote %d bytes differing from Writer's %d", got.Len(), len(want))
+			}
+			if n, again := pw.Close(); n != int64(len(want)) || again != nil {
+				t.Errorf("second pw.Close() = %d, %v; want %d, nil", n, again, len(want))
+			}
+			if err := pw.Submit(data[0]); err != errParallelClosed {
+				t.Errorf("pw.Submit(msg) after Close = %v, want %v", err, errParallelClosed)
+			}
+		})
+	}
+}
+
+func TestParallelWriterCountsFromWriterOffset(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	msg := &testdata.Record{First: proto.Uint64(1)}
+	if err := w.Write(msg); err != nil {
+		t.Fatal(err)
+	}
+	before := w.Offset()
+	pw := NewParallelWriter(w)
+	if err := pw.Submit(msg); err != nil {
+		t.Fatalf("pw.Submit(msg) = %v, want nil", err)
+	}
+	if n, err := pw.Close(); n != before || err != nil {
+		t.Errorf("pw.Close() = %d, %v; want %d, nil", n, err, before)
+	}
+	if got, want := w.Index(), int64(2); got != want {
+		t.Errorf("w.Index() = %d, want %d", got, want)
+	}
+}
+
+// failingWriter is an io.Writer that accepts limit bytes and then fails.
+type failingWriter struct {
+	limit int
+	err   error
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, w.err
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func TestParallelWriterErrors(t *testing.T) {
+	_, data := recordStream(t, WriteDelimitedOptions{}, 100)
+	errBroken := errors.New("broken")
+
+	t.Run("marshal", func(t *testing.T) {
+		var buf bytes.Buffer
+		pw := ParallelOptions{Workers: 4}.NewParallelWriter(NewWriter(&buf))
+		var err error
+		for i, msg := range data {
+			var m proto.Message = msg
+			if i == 10 {
+				m = new(testdata.Required)
+			}
+			if err = pw.Submit(m); err != nil {
+				break
+			}
+		}
+		if _, cerr := pw.Close(); !errors.Is(cerr, proto.Error) || (err != nil && err != cerr) {
+			t.Fatalf("pw.Close() = ?, %v (after pw.Submit(msg) = %v); want ?, %v", cerr, err, proto.Error)
+		}
+		// The records before the failing one are written; none after it are.
+		if got := decodeStream(t, ReadDelimitedOptions{}, buf.Bytes()); !cmp.Equal(got, data[:10], protocmp.Transform()) {
+			t.Errorf("ParallelWriter wrote %v, want %v", got, data[:10])
+		}
+	})
+
+	t.Run("write", func(t *testing.T) {
+		fw := &failingWriter{limit: 100, err: errBroken}
+		pw := ParallelOptions{Workers: 4, InFlight: 2}.NewParallelWriter(NewWriterSize(fw, 16))
+		var err error
+		for _, msg := range data {
+			if err = pw.Submit(msg); err != nil {
+				break
+			}
+		}
+		if err != errBroken {
+			t.Errorf("pw.Submit(msg) = %v, want %v", err, errBroken)
+		}
+		n, cerr := pw.Close()
+		if cerr != errBroken {
+			t.Errorf("pw.Close() = ?, %v; want ?, %v", cerr, errBroken)
+		}
+		if n < 100 {
+			t.Errorf("pw.Close() = %d, ?; want at least the 100 bytes accepted", n)
+		}
+	})
+}
+
+func TestParallelWriterBackpressure(t *testing.T) {
+	r, w := io.Pipe()
+	const inFlight = 3
+	pw := ParallelOptions{Workers: 2, InFlight: inFlight}.NewParallelWriter(NewWriterSize(w, 16))
+	var submitted atomic.Int64
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < 100; i++ {
+			// Each record outgrows the Writer's buffer, so none is held there.
+			msg := &testdata.Record{First: proto.Uint64(uint64(i)), Third: proto.String(strings.Repeat("x", 32))}
+			if err := pw.Submit(msg); err != nil {
+				done <- err
+				return
+			}
+			submitted.Add(1)
+		}
+		_, err := pw.Close()
+		w.Close()
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	// Nobody reads the pipe, so the committer blocks writing the first record
+	// while the others wait behind it.
+	if got, limit := submitted.Load(), int64(inFlight); got > limit {
+		t.Errorf("with a stalled io.Writer, %d records submitted, want at most %d", got, limit)
+	}
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("pw.Close() = ?, %v; want ?, nil", err)
+	}
+	for i, msg := range decodeStream(t, ReadDelimitedOptions{}, got) {
+		if msg.GetFirst() != uint64(i) {
+			t.Fatalf("record %d has first: %d, want %d", i, msg.GetFirst(), i)
+		}
+	}
+}
+
+func BenchmarkParallelReader(b *testing.B) {
+	var buf bytes.Buffer
+	msg := &testdata.Record{First: proto.Uint64(1), Third: proto.String(string(make([]byte, 256)))}
+	for i := 0; i < 1000; i++ {
+		if _, err := WriteDelimited(&buf, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.SetBytes(int64(buf.Len()))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pr := NewParallelReader(NewReader(bytes.NewReader(buf.Bytes())), newRecordMessage)
+		for {
+			if _, err := pr.Next(); err == io.EOF {
+				break
+			} else if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkParallelWriter(b *testing.B) {
+	msg := &testdata.Record{First: proto.Uint64(1), Third: proto.String(string(make([]byte, 256)))}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pw := NewParallelWriter(NewWriter(io.Discard))
+		for j := 0; j < 1000; j++ {
+			if err := pw.Submit(msg); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if _, err := pw.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
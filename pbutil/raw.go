@@ -0,0 +1,86 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"encoding/binary"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// ReadDelimitedBytes reads the next record from the provided length-delimited
+// stream and returns its payload without decoding it.  The framing, the
+// returned byte count, and the error behavior are identical to ReadDelimited,
+// so the payload is exactly what proto.Unmarshal would have been given.
+func ReadDelimitedBytes(r io.Reader) (payload []byte, n int, err error) {
+	return ReadDelimitedOptions{}.ReadDelimitedBytes(r)
+}
+
+// ReadDelimitedBytes behaves like the package-level ReadDelimitedBytes
+// function but honors the options in o.
+func (o ReadDelimitedOptions) ReadDelimitedBytes(r io.Reader) (payload []byte, n int, err error) {
+	cr := &countingReader{r: r}
+	payload, err = o.readPayload(cr, new(scratch), 0)
+	return payload, cr.n, err
+}
+
+// WriteDelimitedBytes writes payload to w prefixed with its length as a varint,
+// exactly as WriteDelimited frames an encoded message.  The payload is not
+// validated.  It returns the total number of bytes written and any applicable
+// error.
+func WriteDelimitedBytes(w io.Writer, payload []byte) (n int, err error) {
+	return WriteDelimitedOptions{}.WriteDelimitedBytes(w, payload)
+}
+
+// WriteDelimitedBytes behaves like the package-level WriteDelimitedBytes
+// function but honors the options in o.
+func (o WriteDelimitedOptions) WriteDelimitedBytes(w io.Writer, payload []byte) (n int, err error) {
+	if o.Compressed {
+		if payload, err = compressPayload(nil, payload, o.Codec); err != nil {
+			return 0, err
+		}
+	}
+	var arr [binary.MaxVarintLen64]byte
+	hdr := protowire.AppendVarint(arr[:0], uint64(len(payload)))
+	n, err = w.Write(hdr)
+	if err != nil {
+		return n, err
+	}
+	m, err := w.Write(payload)
+	n += m
+	if err != nil || !o.Checksum {
+		return n, err
+	}
+	var trailer [checksumLen]byte
+	m, err = w.Write(appendChecksum(trailer[:0], payload))
+	return n + m, err
+}
+
+// NextBytes reads the next record and returns its undecoded payload.  The
+// returned slice aliases the Reader's scratch buffer and is only valid until
+// the next call to a method of the Reader.
+func (r *Reader) NextBytes() ([]byte, error) {
+	return r.nextFrame()
+}
+
+// WriteBytes writes payload as the next record without validating it, framed
+// exactly as WriteDelimitedBytes would frame it.
+func (w *Writer) WriteBytes(payload []byte) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	return w.writeFrame(payload)
+}
@@ -0,0 +1,140 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestDelimitedBytesMatchesMessages(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		msg  proto.Message
+	}{
+		{
+			name: "empty",
+			msg:  new(testdata.Record),
+		},
+		{
+			name: "firstfield",
+			msg:  &testdata.Record{First: proto.Uint64(1)},
+		},
+		{
+			name: "headerlength",
+			msg:  &testdata.Record{Third: proto.String(string(bytes.Repeat([]byte("x"), 200)))},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var want bytes.Buffer
+			wantN, err := WriteDelimited(&want, test.msg)
+			if err != nil {
+				t.Fatalf("WriteDelimited(buf, %v) = ?, %v; want ?, nil", test.msg, err)
+			}
+			payload, err := proto.Marshal(test.msg)
+			if err != nil {
+				t.Fatalf("proto.Marshal(%v) = ?, %v; want ?, nil", test.msg, err)
+			}
+
+			var got bytes.Buffer
+			n, err := WriteDelimitedBytes(&got, payload)
+			if n != wantN || err != nil {
+				t.Errorf("WriteDelimitedBytes(buf, %v) = %v, %v; want %v, nil", payload, n, err, wantN)
+			}
+			if !cmp.Equal(got.Bytes(), want.Bytes()) {
+				t.Errorf("WriteDelimitedBytes(buf, %v) wrote %v, want %v", payload, got.Bytes(), want.Bytes())
+			}
+
+			read, n, err := ReadDelimitedBytes(&got)
+			if n != wantN || err != nil {
+				t.Errorf("ReadDelimitedBytes(buf) = ?, %v, %v; want ?, %v, nil", n, err, wantN)
+			}
+			if !bytes.Equal(read, payload) {
+				t.Errorf("ReadDelimitedBytes(buf) = %v, ?, ?; want %v, ?, ?", read, payload)
+			}
+		})
+	}
+}
+
+func TestReadDelimitedBytesErrors(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   []byte
+		n    int
+		err  error
+	}{
+		{
+			name: "end of stream",
+			err:  io.EOF,
+		},
+		{
+			name: "premature body",
+			in:   []byte{128, 5, 0, 0, 0},
+			n:    5,
+			err:  io.ErrUnexpectedEOF,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			payload, n, err := ReadDelimitedBytes(bytes.NewReader(test.in))
+			if payload != nil || n != test.n || !errors.Is(err, test.err) {
+				t.Errorf("ReadDelimitedBytes(%v) = %v, %v, %v; want nil, %v, %v", test.in, payload, n, err, test.n, test.err)
+			}
+		})
+	}
+}
+
+func TestWriteDelimitedBytesWriteErr(t *testing.T) {
+	n, err := WriteDelimitedBytes(cantWrite{}, []byte{8, 1})
+	if got, want := n, 0; got != want {
+		t.Errorf("WriteDelimitedBytes(buf, payload) = %v, ?; want %v, ?", got, want)
+	}
+	if got, want := err, errWrite; !errors.Is(got, want) {
+		t.Errorf("WriteDelimitedBytes(buf, payload) = ?, %v; want ?, %v", got, want)
+	}
+}
+
+func TestReaderWriterBytesRelay(t *testing.T) {
+	src := []byte{2, 8, 1, 0, 2, 8, 2}
+	var dst bytes.Buffer
+	r := NewReader(bytes.NewReader(src))
+	w := NewWriter(&dst)
+	for {
+		payload, err := r.NextBytes()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("r.NextBytes() = ?, %v; want ?, nil", err)
+		}
+		if err := w.WriteBytes(payload); err != nil {
+			t.Fatalf("w.WriteBytes(%v) = %v, want nil", payload, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+	if !cmp.Equal(dst.Bytes(), src) {
+		t.Errorf("relayed %v, want %v", dst.Bytes(), src)
+	}
+	if got, want := w.Index(), r.Index(); got != want {
+		t.Errorf("w.Index() = %d, want %d", got, want)
+	}
+}
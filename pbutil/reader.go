@@ -0,0 +1,107 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"errors"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// Reader decodes a sequence of messages from a length-delimited stream.  Unlike
+// repeated calls to ReadDelimited, a Reader retains its payload buffer between
+// records, so steady-state reading of similarly sized records does not
+// allocate.  It also tracks its position in the stream.  Unless placed into
+// recovery mode with EnableResync, a Reader never reads more bytes from the
+// underlying stream than the records it returns occupy.
+type Reader struct {
+	opts   ReadDelimitedOptions
+	cr     countingReader
+	s      scratch
+	offset int64
+	index  int64
+	resync *resyncState
+}
+
+// NewReader returns a Reader that decodes records from r with the default
+// options.
+func NewReader(r io.Reader) *Reader {
+	return ReadDelimitedOptions{}.NewReader(r)
+}
+
+// NewReader returns a Reader that decodes records from r according to o.
+func (o ReadDelimitedOptions) NewReader(r io.Reader) *Reader {
+	return &Reader{opts: o, cr: countingReader{r: r}}
+}
+
+// Next decodes the next record from the stream into m.  It returns io.EOF,
+// unwrapped, once the stream ends cleanly on a record boundary.  A stream that
+// ends partway through a record yields an error that matches
+// io.ErrUnexpectedEOF under errors.Is.
+//
+// The scratch buffer holding the encoded record is reused by later calls;
+// m does not retain any reference to it.
+func (r *Reader) Next(m proto.Message) error {
+	if r.resync != nil {
+		return r.nextResync(m)
+	}
+	offset := r.offset
+	buf, err := r.nextFrame()
+	if err != nil {
+		return err
+	}
+	return unmarshalFrame(offset, buf, m)
+}
+
+// nextFrame reads the next record's payload into the scratch buffer and
+// advances the Reader's position accordingly.
+func (r *Reader) nextFrame() ([]byte, error) {
+	r.cr.n = 0
+	buf, err := r.opts.readPayload(&r.cr, &r.s, r.offset)
+	r.offset += int64(r.cr.n)
+	if err == nil || isDiscarded(err) {
+		r.index++
+	}
+	return buf, err
+}
+
+// isDiscarded reports whether err describes an oversized record that was
+// skipped in its entirety.
+func isDiscarded(err error) bool {
+	var tooLarge *SizeTooLargeError
+	return errors.As(err, &tooLarge) && tooLarge.Discarded
+}
+
+// Offset returns the number of bytes consumed from the underlying stream since
+// the Reader was created or last reset.
+func (r *Reader) Offset() int64 { return r.offset }
+
+// Index returns the zero-based index of the next record to be read, which is
+// also the number of records consumed so far.  Records that were consumed but
+// failed to decode, or that were discarded for being oversized, are counted.
+func (r *Reader) Index() int64 { return r.index }
+
+// Reset discards the Reader's position and switches it to reading from src.
+// The scratch buffer and recovery mode, if enabled, are retained.
+func (r *Reader) Reset(src io.Reader) {
+	r.cr = countingReader{r: src}
+	if r.resync != nil {
+		r.resync.src = replayReader{r: src, frame: r.resync.src.frame[:0]}
+		r.cr.r = &r.resync.src
+	}
+	r.offset = 0
+	r.index = 0
+}
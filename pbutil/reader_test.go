@@ -0,0 +1,147 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestReaderNext(t *testing.T) {
+	data := []*testdata.Record{
+		{First: proto.Uint64(1)},
+		{},
+		{Third: proto.String("third")},
+	}
+	var buf bytes.Buffer
+	var offsets []int64
+	for i, msg := range data {
+		offsets = append(offsets, int64(buf.Len()))
+		if _, err := WriteDelimited(&buf, msg); err != nil {
+			t.Fatalf("WriteDelimited(buf, data[%d]) = ?, %v; want ?, nil", i, err)
+		}
+	}
+	offsets = append(offsets, int64(buf.Len()))
+
+	r := NewReader(iotest.HalfReader(&buf))
+	for i, want := range data {
+		if got, want := r.Index(), int64(i); got != want {
+			t.Errorf("before record %d, r.Index() = %d, want %d", i, got, want)
+		}
+		if got, want := r.Offset(), offsets[i]; got != want {
+			t.Errorf("before record %d, r.Offset() = %d, want %d", i, got, want)
+		}
+		var got testdata.Record
+		if err := r.Next(&got); err != nil {
+			t.Fatalf("r.Next(&msg) for record %d = %v, want nil", i, err)
+		}
+		if !cmp.Equal(&got, want, protocmp.Transform()) {
+			t.Errorf("r.Next(&msg) for record %d; msg = %v, want %v", i, &got, want)
+		}
+	}
+	if got, want := r.Next(new(testdata.Record)), io.EOF; got != want {
+		t.Errorf("r.Next(&msg) at end = %v, want %v", got, want)
+	}
+	if got, want := r.Index(), int64(len(data)); got != want {
+		t.Errorf("at end, r.Index() = %d, want %d", got, want)
+	}
+	if got, want := r.Offset(), offsets[len(data)]; got != want {
+		t.Errorf("at end, r.Offset() = %d, want %d", got, want)
+	}
+}
+
+func TestReaderTruncated(t *testing.T) {
+	data := []byte{2, 8, 1, 5, 8}
+	r := NewReader(bytes.NewReader(data))
+	if err := r.Next(new(testdata.Record)); err != nil {
+		t.Fatalf("r.Next(&msg) = %v, want nil", err)
+	}
+	if got, want := r.Next(new(testdata.Record)), io.ErrUnexpectedEOF; !errors.Is(got, want) {
+		t.Errorf("r.Next(&msg) = %v, want %v", got, want)
+	}
+	if got, want := r.Offset(), int64(len(data)); got != want {
+		t.Errorf("r.Offset() = %d, want %d", got, want)
+	}
+	if got, want := r.Index(), int64(1); got != want {
+		t.Errorf("r.Index() = %d, want %d", got, want)
+	}
+}
+
+func TestReaderDiscardCounts(t *testing.T) {
+	data := []byte{3, 8, 1, 0, 2, 8, 2}
+	r := ReadDelimitedOptions{MaxSize: 2, DiscardOversized: true}.NewReader(bytes.NewReader(data))
+	var msg testdata.Record
+	if err := r.Next(&msg); err == nil {
+		t.Fatalf("r.Next(&msg) = nil, want *SizeTooLargeError")
+	}
+	if err := r.Next(&msg); err != nil {
+		t.Fatalf("r.Next(&msg) = %v, want nil", err)
+	}
+	if got, want := r.Index(), int64(2); got != want {
+		t.Errorf("r.Index() = %d, want %d", got, want)
+	}
+	if got, want := msg.GetFirst(), uint64(2); got != want {
+		t.Errorf("msg.GetFirst() = %d, want %d", got, want)
+	}
+}
+
+func TestReaderReset(t *testing.T) {
+	r := NewReader(bytes.NewReader([]byte{2, 8, 1}))
+	if err := r.Next(new(testdata.Record)); err != nil {
+		t.Fatalf("r.Next(&msg) = %v, want nil", err)
+	}
+	r.Reset(bytes.NewReader([]byte{0}))
+	if got, want := r.Offset(), int64(0); got != want {
+		t.Errorf("after Reset, r.Offset() = %d, want %d", got, want)
+	}
+	if err := r.Next(new(testdata.Record)); err != nil {
+		t.Fatalf("after Reset, r.Next(&msg) = %v, want nil", err)
+	}
+	if got, want := r.Index(), int64(1); got != want {
+		t.Errorf("after Reset, r.Index() = %d, want %d", got, want)
+	}
+}
+
+func BenchmarkReaderNext(b *testing.B) {
+	var buf bytes.Buffer
+	msg := &testdata.Record{First: proto.Uint64(1), Third: proto.String("benchmark")}
+	for i := 0; i < 1024; i++ {
+		if _, err := WriteDelimited(&buf, msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+	data := buf.Bytes()
+	var out testdata.Record
+	br := bytes.NewReader(data)
+	r := NewReader(br)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := r.Next(&out); err == io.EOF {
+			br.Reset(data)
+			r.Reset(br)
+		} else if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"errors"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var errImplausible = errors.New("pbutil: implausible record while resynchronizing")
+
+// replayReader reads first from bytes pushed back after a failed
+// resynchronization attempt and then from r.  While capturing, every byte
+// read is also retained in frame, so that it can in turn be pushed back.
+type replayReader struct {
+	r       io.Reader
+	pending []byte
+	capture bool
+	frame   []byte
+	// err is the first error other than io.EOF returned by r.  Such an error
+	// is a failure of the underlying stream rather than a sign of corruption,
+	// so it is never resynchronized past.
+	err error
+}
+
+// implements io.Reader
+func (rr *replayReader) Read(p []byte) (n int, err error) {
+	if len(rr.pending) > 0 {
+		n = copy(p, rr.pending)
+		rr.pending = rr.pending[n:]
+	} else {
+		n, err = rr.r.Read(p)
+		if err != nil && err != io.EOF && rr.err == nil {
+			rr.err = err
+		}
+	}
+	if rr.capture {
+		rr.frame = append(rr.frame, p[:n]...)
+	}
+	return n, err
+}
+
+// unread pushes all captured bytes after the first skip back onto the stream.
+func (rr *replayReader) unread(skip int) {
+	rest := rr.frame[skip:]
+	pending := make([]byte, 0, len(rest)+len(rr.pending))
+	pending = append(pending, rest...)
+	rr.pending = append(pending, rr.pending...)
+}
+
+// resyncState holds the configuration and buffers of a Reader in recovery
+// mode.
+type resyncState struct {
+	src     replayReader
+	skipped func(offset, length int64)
+}
+
+// EnableResync places the Reader into recovery mode.  In recovery mode, when
+// Next encounters a record that is malformed, truncated, oversized, or fails
+// to decode, it does not return an error.  Instead it advances one byte at a
+// time, treating each position as a candidate record, until a candidate
+// decodes successfully into the provided message or the stream ends.  Each
+// contiguous run of bytes passed over is reported to skipped, if it is not
+// nil, as an offset and length within the stream.
+//
+// While resynchronizing, a candidate is accepted only if it is non-empty and
+// decodes without any unknown fields; otherwise any stray zero byte, or almost
+// any short run of bytes, would qualify.  Errors from the underlying
+// io.Reader are still returned, as is a record discarded under
+// DiscardOversized when it is encountered outside of resynchronization.
+//
+// Resynchronization must buffer each candidate record, including any data it
+// claims to hold, so callers should bound that work by setting MaxSize.
+// Candidates exceeding MaxSize are rejected after their length prefix, even
+// under DiscardOversized, which only applies outside of resynchronization.  In
+// recovery mode, the Reader may read past the end of the record it returns;
+// Offset continues to report the logical position in the stream.  Recovery
+// applies only to Next; NextBytes and Skip do not validate records.
+func (r *Reader) EnableResync(skipped func(offset, length int64)) {
+	if r.resync == nil {
+		r.resync = &resyncState{src: replayReader{r: r.cr.r}}
+		r.cr.r = &r.resync.src
+	}
+	r.resync.skipped = skipped
+}
+
+// nextResync implements Next in recovery mode.
+func (r *Reader) nextResync(m proto.Message) error {
+	rs := r.resync
+	skipFrom := int64(-1)
+	for {
+		start, index := r.offset, r.index
+		rs.src.frame = rs.src.frame[:0]
+		rs.src.capture = true
+		// A candidate within corrupt data often declares a huge length.
+		// Discarding its claimed payload would read, and capture for
+		// replay, up to the rest of the stream at every position, so
+		// reject such candidates right after their length prefix.
+		opts := r.opts
+		if skipFrom >= 0 {
+			r.opts.DiscardOversized = false
+		}
+		buf, err := r.nextFrame()
+		r.opts = opts
+		if err == nil {
+			err = r.validate(start, buf, m, skipFrom >= 0)
+		}
+		rs.src.capture = false
+		if err == nil || err == io.EOF || rs.src.err != nil || isDiscarded(err) && skipFrom < 0 {
+			if skipFrom >= 0 && rs.skipped != nil {
+				rs.skipped(skipFrom, start-skipFrom)
+			}
+			return err
+		}
+		if skipFrom < 0 {
+			skipFrom = start
+		}
+		rs.src.unread(1)
+		r.offset = start + 1
+		r.index = index
+	}
+}
+
+// validate decodes the payload of the candidate record at offset into m.  When
+// strict, the candidate must also be plausible as described by EnableResync.
+func (r *Reader) validate(offset int64, payload []byte, m proto.Message, strict bool) error {
+	if err := unmarshalFrame(offset, payload, m); err != nil {
+		return err
+	}
+	if strict && (len(payload) == 0 || len(m.ProtoReflect().GetUnknown()) != 0) {
+		return errImplausible
+	}
+	return nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+// skippedRange is an offset and length reported to an EnableResync callback.
+type skippedRange struct {
+	Offset, Length int64
+}
+
+// frame returns the delimited encoding of a Record holding s.
+func frame(t *testing.T, s string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := WriteDelimited(&buf, &testdata.Record{Third: proto.String(s)}); err != nil {
+		t.Fatalf("WriteDelimited(buf, %q) = ?, %v; want ?, nil", s, err)
+	}
+	return buf.Bytes()
+}
+
+// readAllResync reads every record of data in recovery mode with opts,
+// returning the decoded strings, the skipped ranges, and the terminal error.
+func readAllResync(opts ReadDelimitedOptions, data []byte) ([]string, []skippedRange, error) {
+	var skipped []skippedRange
+	r := opts.NewReader(iotest.OneByteReader(bytes.NewReader(data)))
+	r.EnableResync(func(offset, length int64) {
+		skipped = append(skipped, skippedRange{offset, length})
+	})
+	var got []string
+	for {
+		var msg testdata.Record
+		if err := r.Next(&msg); err != nil {
+			return got, skipped, err
+		}
+		got = append(got, msg.GetThird())
+	}
+}
+
+func TestResync(t *testing.T) {
+	alpha, bravo, charlie := frame(t, "alpha"), frame(t, "bravo"), frame(t, "charlie")
+	garbage := []byte{255, 255, 3, 250, 17}
+	corrupt := append([]byte{60}, bravo[1:]...)
+
+	for _, test := range []struct {
+		name    string
+		data    [][]byte
+		want    []string
+		skipped []skippedRange
+	}{
+		{
+			name: "clean",
+			data: [][]byte{alpha, bravo},
+			want: []string{"alpha", "bravo"},
+		},
+		{
+			name:    "garbage between records",
+			data:    [][]byte{alpha, garbage, bravo, charlie},
+			want:    []string{"alpha", "bravo", "charlie"},
+			skipped: []skippedRange{{int64(len(alpha)), int64(len(garbage))}},
+		},
+		{
+			name:    "corrupt length prefix",
+			data:    [][]byte{alpha, corrupt, charlie},
+			want:    []string{"alpha", "charlie"},
+			skipped: []skippedRange{{int64(len(alpha)), int64(len(corrupt))}},
+		},
+		{
+			name:    "trailing garbage",
+			data:    [][]byte{alpha, garbage},
+			want:    []string{"alpha"},
+			skipped: []skippedRange{{int64(len(alpha)), int64(len(garbage))}},
+		},
+		{
+			name:    "leading garbage",
+			data:    [][]byte{garbage, alpha},
+			want:    []string{"alpha"},
+			skipped: []skippedRange{{0, int64(len(garbage))}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data := bytes.Join(test.data, nil)
+			got, skipped, err := readAllResync(ReadDelimitedOptions{MaxSize: 64}, data)
+			if err != io.EOF {
+				t.Errorf("reading %v in recovery mode ended with %v, want %v", data, err, io.EOF)
+			}
+			if !cmp.Equal(got, test.want) {
+				t.Errorf("reading %v in recovery mode decoded %q, want %q", data, got, test.want)
+			}
+			if !cmp.Equal(skipped, test.skipped) {
+				t.Errorf("reading %v in recovery mode skipped %v, want %v", data, skipped, test.skipped)
+			}
+		})
+	}
+}
+
+func TestResyncDiscardOversized(t *testing.T) {
+	alpha, bravo := frame(t, "alpha"), frame(t, "bravo")
+	// Random corruption is full of candidates declaring huge lengths.  Its
+	// first candidate is small, so resynchronization starts there rather than
+	// DiscardOversized reporting it.
+	garbage := make([]byte, 8<<10)
+	rand.New(rand.NewSource(1)).Read(garbage)
+	garbage[0] = 1
+	data := bytes.Join([][]byte{alpha, garbage, bravo}, nil)
+
+	var want []string
+	var wantSkipped []skippedRange
+	var wantErr error
+	plain := allocatedPerCall(1, func() {
+		want, wantSkipped, wantErr = readAllResync(ReadDelimitedOptions{MaxSize: 64}, data)
+	})
+	var got []string
+	var gotSkipped []skippedRange
+	var gotErr error
+	discarding := allocatedPerCall(1, func() {
+		got, gotSkipped, gotErr = readAllResync(ReadDelimitedOptions{MaxSize: 64, DiscardOversized: true}, data)
+	})
+	if gotErr != wantErr || !cmp.Equal(got, want) || !cmp.Equal(gotSkipped, wantSkipped) {
+		t.Errorf("with DiscardOversized, recovery read %q, skipped %v, ended with %v; want %q, %v, %v", got, gotSkipped, gotErr, want, wantSkipped, wantErr)
+	}
+	if len(got) < 2 || got[0] != "alpha" || got[len(got)-1] != "bravo" {
+		t.Errorf("recovery read %q, want alpha first and bravo last", got)
+	}
+	// Discarding candidates' claimed payloads would capture and replay up to
+	// the rest of the stream at every byte of the corruption.
+	if discarding > 2*plain {
+		t.Errorf("with DiscardOversized, recovery allocated %d bytes, want at most twice the %d without", discarding, plain)
+	}
+}
+
+func TestResyncOffsets(t *testing.T) {
+	alpha, bravo := frame(t, "alpha"), frame(t, "bravo")
+	garbage := []byte{255, 255, 3}
+	data := bytes.Join([][]byte{alpha, garbage, bravo}, nil)
+	r := NewReader(bytes.NewReader(data))
+	r.EnableResync(nil)
+	for i := 0; i < 2; i++ {
+		if err := r.Next(new(testdata.Record)); err != nil {
+			t.Fatalf("r.Next(&msg) for record %d = %v, want nil", i, err)
+		}
+	}
+	if got, want := r.Offset(), int64(len(data)); got != want {
+		t.Errorf("r.Offset() = %d, want %d", got, want)
+	}
+	if got, want := r.Index(), int64(2); got != want {
+		t.Errorf("r.Index() = %d, want %d", got, want)
+	}
+}
+
+func TestResyncReadErr(t *testing.T) {
+	errRead := errors.New("pbutil: can't read")
+	data := append(frame(t, "alpha"), 255, 255)
+	r := NewReader(io.MultiReader(bytes.NewReader(data), iotest.ErrReader(errRead)))
+	r.EnableResync(func(offset, length int64) {
+		t.Errorf("skipped(%d, %d) called, want no calls", offset, length)
+	})
+	if err := r.Next(new(testdata.Record)); err != nil {
+		t.Fatalf("r.Next(&msg) = %v, want nil", err)
+	}
+	if got, want := r.Next(new(testdata.Record)), errRead; !errors.Is(got, want) {
+		t.Errorf("r.Next(&msg) = %v, want %v", got, want)
+	}
+}
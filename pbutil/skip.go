@@ -0,0 +1,93 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// SkipDelimited advances past the next record of the provided length-delimited
+// stream without decoding it.  Only the varint prefix is interpreted; the
+// payload is read and discarded.  It returns the total number of bytes
+// consumed, and reports errors in the same manner as ReadDelimited: (0, io.EOF)
+// at a clean end of stream, and io.ErrUnexpectedEOF if the stream ends within
+// the record.
+func SkipDelimited(r io.Reader) (n int, err error) {
+	return ReadDelimitedOptions{}.SkipDelimited(r)
+}
+
+// SkipDelimited behaves like the package-level SkipDelimited function but
+// honors the framing options in o.  Checksums are skipped without being
+// verified, and MaxSize does not apply.
+func (o ReadDelimitedOptions) SkipDelimited(r io.Reader) (n int, err error) {
+	cr := &countingReader{r: r}
+	err = o.skipFrame(cr, 0)
+	return cr.n, err
+}
+
+// skipFrame reads the next record's header from cr and discards its payload.
+// Errors are reported as for readFrame.
+func (o ReadDelimitedOptions) skipFrame(cr *countingReader, offset int64) error {
+	size, err := readHeader(cr)
+	if err != nil {
+		return frameError(offset, StageHeader, 0, err)
+	}
+	return frameError(offset, StagePayload, size, discard(cr, size+o.trailerLen()))
+}
+
+// Skip advances past the next record without decoding it, as per
+// SkipDelimited.  The record counts toward Index and Offset.  MaxSize does not
+// apply, since no buffer is allocated for the payload.
+func (r *Reader) Skip() error {
+	r.cr.n = 0
+	err := r.opts.skipFrame(&r.cr, r.offset)
+	r.offset += int64(r.cr.n)
+	if err == nil {
+		r.index++
+	}
+	return err
+}
+
+// PeekLength decodes the varint prefix of the next record in r without
+// consuming any bytes.  It returns the declared length of the record's payload
+// and the length of the prefix itself, so the complete record occupies
+// size+headerLen bytes.  PeekLength consumes nothing from r, so the next read
+// from r still begins at the prefix, though peeking may fill r's buffer from
+// the underlying reader.  As with ReadDelimited, io.EOF is returned only if r
+// is exhausted.
+func PeekLength(r *bufio.Reader) (size uint64, headerLen int, err error) {
+	var buf []byte
+	for i := 1; ; i++ {
+		buf, err = r.Peek(i)
+		if err != nil {
+			if err == io.EOF && len(buf) != 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return 0, 0, err
+		}
+		if buf[i-1] < 0x80 || i == binary.MaxVarintLen64 {
+			break
+		}
+	}
+	size, headerLen = protowire.ConsumeVarint(buf)
+	if headerLen < 0 {
+		return 0, 0, protowire.ParseError(headerLen)
+	}
+	return size, headerLen, nil
+}
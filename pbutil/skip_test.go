@@ -0,0 +1,169 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+)
+
+func TestSkipDelimited(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		in   []byte
+		n    int
+		err  error
+	}{
+		{
+			name: "empty record",
+			in:   []byte{0, 2, 8, 1},
+			n:    1,
+		},
+		{
+			name: "multibyte header",
+			in:   append([]byte{128, 1}, make([]byte, 128)...),
+			n:    130,
+		},
+		{
+			name: "end of stream",
+			in:   nil,
+			n:    0,
+			err:  io.EOF,
+		},
+		{
+			name: "premature header",
+			in:   []byte{128},
+			n:    1,
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name: "premature body",
+			in:   []byte{128, 5, 0, 0, 0},
+			n:    5,
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name: "infinite continuation bits",
+			in:   bytes.Repeat([]byte{255}, 2*binary.MaxVarintLen64),
+			n:    binary.MaxVarintLen64,
+			err:  errAny,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			n, err := SkipDelimited(iotest.OneByteReader(bytes.NewReader(test.in)))
+			if got, want := n, test.n; !cmp.Equal(got, want) {
+				t.Errorf("SkipDelimited(%v) = %v, ?; want %v, ?", test.in, got, want)
+			}
+			if !matchErr(err, test.err) {
+				t.Errorf("SkipDelimited(%v) = ?, %v; want ?, %v", test.in, err, test.err)
+			}
+		})
+	}
+}
+
+func TestReaderSkip(t *testing.T) {
+	data := []byte{2, 8, 1, 2, 8, 2}
+	r := NewReader(bytes.NewReader(data))
+	if err := r.Skip(); err != nil {
+		t.Fatalf("r.Skip() = %v, want nil", err)
+	}
+	if got, want := r.Index(), int64(1); got != want {
+		t.Errorf("r.Index() = %d, want %d", got, want)
+	}
+	if got, want := r.Offset(), int64(3); got != want {
+		t.Errorf("r.Offset() = %d, want %d", got, want)
+	}
+	var msg testdata.Record
+	if err := r.Next(&msg); err != nil {
+		t.Fatalf("r.Next(&msg) = %v, want nil", err)
+	}
+	if got, want := msg.GetFirst(), uint64(2); got != want {
+		t.Errorf("msg.GetFirst() = %d, want %d", got, want)
+	}
+}
+
+func TestPeekLength(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		in        []byte
+		size      uint64
+		headerLen int
+		err       error
+	}{
+		{
+			name:      "single byte",
+			in:        []byte{2, 8, 1},
+			size:      2,
+			headerLen: 1,
+		},
+		{
+			name:      "followed by more",
+			in:        append([]byte{2, 8, 1}, make([]byte, 1000)...),
+			size:      2,
+			headerLen: 1,
+		},
+		{
+			name:      "multibyte header",
+			in:        []byte{141, 2},
+			size:      269,
+			headerLen: 2,
+		},
+		{
+			name: "end of stream",
+			err:  io.EOF,
+		},
+		{
+			name: "premature header",
+			in:   []byte{128},
+			err:  io.ErrUnexpectedEOF,
+		},
+		{
+			name: "all 0xFF",
+			in:   bytes.Repeat([]byte{255}, binary.MaxVarintLen64),
+			err:  errAny,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			br := bufio.NewReader(bytes.NewReader(test.in))
+			size, headerLen, err := PeekLength(br)
+			if size != test.size || headerLen != test.headerLen || !matchErr(err, test.err) {
+				t.Errorf("PeekLength(%v) = %v, %v, %v; want %v, %v, %v", test.in, size, headerLen, err, test.size, test.headerLen, test.err)
+			}
+			if got, err := io.ReadAll(br); !bytes.Equal(got, test.in) || err != nil {
+				t.Errorf("after PeekLength(%v), reading yields %v, %v; want %v, nil (nothing consumed)", test.in, got, err, test.in)
+			}
+		})
+	}
+}
+
+// errAny is a sentinel used in test tables to accept any non-nil error.
+var errAny = errors.New("any error")
+
+// matchErr reports whether got satisfies the expectation want, which may be
+// nil, errAny, or an error that got must match under errors.Is.
+func matchErr(got, want error) bool {
+	if want == errAny {
+		return got != nil
+	}
+	return errors.Is(got, want)
+}
@@ -0,0 +1,169 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+// AppendDelimited appends m to b as a length-delimited record, exactly as
+// WriteDelimited would write it, and returns the extended slice.  If m fails
+// to marshal, b is returned unchanged alongside the error.
+func AppendDelimited(b []byte, m proto.Message) ([]byte, error) {
+	return WriteDelimitedOptions{}.AppendDelimited(b, m)
+}
+
+// AppendDelimited behaves like the package-level AppendDelimited function but
+// honors the options in o.
+func (o WriteDelimitedOptions) AppendDelimited(b []byte, m proto.Message) ([]byte, error) {
+	if !o.Compressed {
+		// Marshal in place behind the length prefix, as protodelim does.
+		mo := proto.MarshalOptions{UseCachedSize: true}
+		size := proto.Size(m)
+		out := protowire.AppendVarint(b, uint64(size))
+		start := len(out)
+		out, err := mo.MarshalAppend(out, m)
+		if err != nil {
+			return b, err
+		}
+		if o.Checksum {
+			out = appendChecksum(out, out[start:])
+		}
+		return out, nil
+	}
+	payload, err := proto.Marshal(m)
+	if err != nil {
+		return b, err
+	}
+	stored, err := compressPayload(nil, payload, o.Codec)
+	if err != nil {
+		return b, err
+	}
+	out := protowire.AppendVarint(b, uint64(len(stored)))
+	out = append(out, stored...)
+	if o.Checksum {
+		out = appendChecksum(out, stored)
+	}
+	return out, nil
+}
+
+// ConsumeDelimited decodes the length-delimited record at the start of b into
+// m.  It returns the remainder of b following the record and the number of
+// bytes consumed, which are exactly those ReadDelimited would have consumed
+// from a stream holding b, including on error.  An empty b yields io.EOF.
+// The payload is decoded in place, and m retains no reference to b.
+func ConsumeDelimited(b []byte, m proto.Message) (rest []byte, n int, err error) {
+	return ReadDelimitedOptions{}.ConsumeDelimited(b, m)
+}
+
+// ConsumeDelimited behaves like the package-level ConsumeDelimited function
+// but honors the options in o.
+func (o ReadDelimitedOptions) ConsumeDelimited(b []byte, m proto.Message) (rest []byte, n int, err error) {
+	payload, n, err := o.consumePayload(b, 0)
+	if err != nil {
+		return b[n:], n, err
+	}
+	return b[n:], n, unmarshalFrame(0, payload, m)
+}
+
+// consumePayload is the counterpart of readPayload for records held in b: it
+// returns the message bytes of the record at the start of b, decompressing them
+// if the options call for it, and the number of bytes that readPayload would
+// have consumed.  Unless decompressed, the message bytes alias b.  Errors are
+// reported as a *FrameError for the record beginning at offset.
+func (o ReadDelimitedOptions) consumePayload(b []byte, offset int64) (payload []byte, n int, err error) {
+	stored, n, err := o.consumeFrame(b, offset)
+	if err != nil || !o.Compressed {
+		return stored, n, err
+	}
+	plain, err := decompressPayload(nil, stored, int(o.maxSize()))
+	if err != nil {
+		return nil, n, frameError(offset, StageDecompress, uint64(len(stored)), err)
+	}
+	return plain, n, nil
+}
+
+// consumeFrame returns the stored payload of the record at the start of b,
+// which aliases b, and the number of bytes that readFrame would have consumed
+// reading it from a stream.
+func (o ReadDelimitedOptions) consumeFrame(b []byte, offset int64) (payload []byte, n int, err error) {
+	size, n, err := consumeHeader(b)
+	if err != nil {
+		return nil, n, frameError(offset, StageHeader, 0, err)
+	}
+	avail := uint64(len(b) - n)
+	if skipped, err := o.rejectOversized(size, avail, offset); err != nil {
+		return nil, n + int(skipped), err
+	}
+	if size > avail {
+		return nil, len(b), frameError(offset, StagePayload, size, io.ErrUnexpectedEOF)
+	}
+	payload = b[n : n+int(size)]
+	n += int(size)
+	if o.Checksum {
+		if len(b)-n < checksumLen {
+			return nil, len(b), frameError(offset, StageChecksum, size, io.ErrUnexpectedEOF)
+		}
+		stored := binary.LittleEndian.Uint32(b[n:])
+		n += checksumLen
+		if computed := crc32.Checksum(payload, castagnoli); stored != computed {
+			return nil, n, frameError(offset, StageChecksum, size, &ChecksumError{Stored: stored, Computed: computed})
+		}
+	}
+	return payload, n, nil
+}
+
+// rejectOversized reports a *FrameError if size exceeds the options' limit,
+// where avail bytes follow the record's prefix.  It also returns how many of
+// those bytes readFrame would have consumed in discarding the record.
+func (o ReadDelimitedOptions) rejectOversized(size, avail uint64, offset int64) (skipped uint64, err error) {
+	maxSize := o.maxSize()
+	if size <= uint64(maxSize) {
+		return 0, nil
+	}
+	tooLarge := &SizeTooLargeError{Size: size, MaxSize: maxSize}
+	if o.DiscardOversized {
+		if size > avail || avail-size < o.trailerLen() {
+			return avail, frameError(offset, StagePayload, size, io.ErrUnexpectedEOF)
+		}
+		skipped = size + o.trailerLen()
+		tooLarge.Discarded = true
+	}
+	return skipped, frameError(offset, StageSize, size, tooLarge)
+}
+
+// consumeHeader parses the varint length prefix at the start of b, consuming
+// the same bytes that readHeader would.
+func consumeHeader(b []byte) (size uint64, n int, err error) {
+	if len(b) == 0 {
+		return 0, 0, io.EOF
+	}
+	for n < len(b) && n < binary.MaxVarintLen64 {
+		n++
+		if b[n-1] < 0x80 {
+			break
+		}
+	}
+	size, m := protowire.ConsumeVarint(b[:n])
+	if m < 0 {
+		return 0, n, protowire.ParseError(m)
+	}
+	return size, n, nil
+}
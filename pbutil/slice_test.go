@@ -0,0 +1,183 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+var sliceOptions = []struct {
+	name  string
+	read  ReadDelimitedOptions
+	write WriteDelimitedOptions
+}{
+	{name: "plain"},
+	{
+		name:  "checksummed",
+		read:  ReadDelimitedOptions{Checksum: true},
+		write: WriteDelimitedOptions{Checksum: true},
+	},
+	{
+		name:  "compressed",
+		read:  ReadDelimitedOptions{Compressed: true},
+		write: WriteDelimitedOptions{Compressed: true, Codec: CodecGzip},
+	},
+	{
+		name:  "checksummed and compressed",
+		read:  ReadDelimitedOptions{Checksum: true, Compressed: true},
+		write: WriteDelimitedOptions{Checksum: true, Compressed: true, Codec: CodecFlate},
+	},
+}
+
+func TestAppendDelimited(t *testing.T) {
+	data := []proto.Message{
+		new(testdata.Record),
+		&testdata.Record{First: proto.Uint64(1)},
+		&testdata.Record{Third: proto.String(string(make([]byte, 300)))},
+	}
+	for _, test := range sliceOptions {
+		t.Run(test.name, func(t *testing.T) {
+			prefix := []byte("prefix")
+			got := append([]byte(nil), prefix...)
+			want := bytes.NewBuffer(append([]byte(nil), prefix...))
+			for i, msg := range data {
+				var err error
+				if got, err = test.write.AppendDelimited(got, msg); err != nil {
+					t.Fatalf("AppendDelimited(b, data[%d]) = ?, %v; want ?, nil", i, err)
+				}
+				if _, err := test.write.WriteDelimited(want, msg); err != nil {
+					t.Fatalf("WriteDelimited(buf, data[%d]) = ?, %v; want ?, nil", i, err)
+				}
+			}
+			if !bytes.Equal(got, want.Bytes()) {
+				t.Errorf("AppendDelimited(b, data...) = %v, want %v", got, want.Bytes())
+			}
+		})
+	}
+}
+
+func TestAppendDelimitedMarshalError(t *testing.T) {
+	b := []byte{1, 2, 3}
+	got, err := AppendDelimited(b, new(testdata.Required))
+	if !errors.Is(err, proto.Error) || !bytes.Equal(got, b) {
+		t.Errorf("AppendDelimited(b, new(testdata.Required)) = %v, %v; want %v, %v", got, err, b, proto.Error)
+	}
+}
+
+func TestConsumeDelimited(t *testing.T) {
+	for _, test := range sliceOptions {
+		t.Run(test.name, func(t *testing.T) {
+			stream, data := recordStream(t, test.write, 50)
+			b := stream
+			var got []*testdata.Record
+			var total int
+			for {
+				msg := new(testdata.Record)
+				rest, n, err := test.read.ConsumeDelimited(b, msg)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("ConsumeDelimited(b, &msg) for record %d = ?, ?, %v; want ?, ?, nil", len(got), err)
+				}
+				if len(rest) != len(b)-n {
+					t.Fatalf("ConsumeDelimited(b, &msg) = %d bytes remaining, %d consumed; want them to sum to %d", len(rest), n, len(b))
+				}
+				got = append(got, msg)
+				total += n
+				b = rest
+			}
+			if total != len(stream) {
+				t.Errorf("ConsumeDelimited consumed %d bytes in all, want %d", total, len(stream))
+			}
+			if !cmp.Equal(got, data, protocmp.Transform()) {
+				t.Errorf("ConsumeDelimited decoded %v, want %v", got, data)
+			}
+		})
+	}
+}
+
+func TestConsumeDelimitedMatchesReadDelimited(t *testing.T) {
+	record, err := AppendDelimited(nil, &testdata.Record{First: proto.Uint64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checksummed, err := WriteDelimitedOptions{Checksum: true}.AppendDelimited(nil, &testdata.Record{First: proto.Uint64(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	corrupted := append([]byte(nil), checksummed...)
+	corrupted[len(corrupted)-1] ^= 0xff
+	for _, test := range []struct {
+		name  string
+		opts  ReadDelimitedOptions
+		input []byte
+	}{
+		{name: "empty"},
+		{name: "complete", input: record},
+		{name: "followed by more", input: append(append([]byte(nil), record...), record...)},
+		{name: "truncated header", input: []byte{0x80, 0x80}},
+		{name: "overlong header", input: bytes.Repeat([]byte{0xff}, 12)},
+		{name: "truncated payload", input: record[:len(record)-1]},
+		{name: "invalid payload", input: []byte{2, 0xff, 0xff, 0}},
+		{name: "oversized", opts: ReadDelimitedOptions{MaxSize: 1}, input: record},
+		{name: "oversized and discarded", opts: ReadDelimitedOptions{MaxSize: 1, DiscardOversized: true}, input: append(append([]byte(nil), record...), record...)},
+		{name: "oversized and truncated", opts: ReadDelimitedOptions{MaxSize: 1, DiscardOversized: true}, input: record[:len(record)-1]},
+		{name: "checksum", opts: ReadDelimitedOptions{Checksum: true}, input: checksummed},
+		{name: "checksum mismatch", opts: ReadDelimitedOptions{Checksum: true}, input: corrupted},
+		{name: "checksum truncated", opts: ReadDelimitedOptions{Checksum: true}, input: checksummed[:len(checksummed)-2]},
+		{name: "not compressed", opts: ReadDelimitedOptions{Compressed: true}, input: []byte{1, 0xfe}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var want testdata.Record
+			wantN, wantErr := test.opts.ReadDelimited(bytes.NewReader(test.input), &want)
+			var got testdata.Record
+			rest, n, err := test.opts.ConsumeDelimited(test.input, &got)
+			if n != wantN || fmt.Sprint(err) != fmt.Sprint(wantErr) {
+				t.Errorf("ConsumeDelimited(b, &msg) = ?, %d, %v; want ?, %d, %v", n, err, wantN, wantErr)
+			}
+			if !bytes.Equal(rest, test.input[n:]) {
+				t.Errorf("ConsumeDelimited(b, &msg) = %v, ?, ?; want %v", rest, test.input[n:])
+			}
+			if !cmp.Equal(&got, &want, protocmp.Transform()) {
+				t.Errorf("ConsumeDelimited(b, &msg); msg = %v, want %v", &got, &want)
+			}
+		})
+	}
+}
+
+func BenchmarkConsumeDelimited(b *testing.B) {
+	stream, err := AppendDelimited(nil, &testdata.Record{First: proto.Uint64(1), Third: proto.String(string(make([]byte, 256)))})
+	if err != nil {
+		b.Fatal(err)
+	}
+	var msg testdata.Record
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := ConsumeDelimited(stream, &msg); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,274 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// A tagged stream may interleave records of different message types.  Each
+// record's payload is an envelope encoded as if it were this message:
+//
+//	message TaggedRecord {
+//	  string type_url = 1;
+//	  bytes value = 2;
+//	  uint64 type_id = 3;
+//	}
+//
+// Exactly one of type_url and type_id is set.  An envelope bearing a type_url
+// is wire-compatible with google.protobuf.Any, so a tagged stream written
+// without TypeIDs may be read as a stream of Any messages.
+const (
+	taggedURLField   = 1
+	taggedValueField = 2
+	taggedIDField    = 3
+)
+
+// typeURLPrefix is the prefix of the type URLs written to tagged streams,
+// matching that used by anypb.
+const typeURLPrefix = "type.googleapis.com/"
+
+var errUntagged = errors.New("pbutil: tagged record names no type")
+
+// TaggedOptions configures the reading and writing of tagged streams.  Readers
+// and writers of a stream must agree on TypeIDs.
+type TaggedOptions struct {
+	// Resolver resolves the types named by records.  If it also implements
+	// protoregistry.ExtensionTypeResolver, it resolves extensions too.  The
+	// zero value uses protoregistry.GlobalTypes.
+	Resolver protoregistry.MessageTypeResolver
+
+	// TypeIDs assigns small integers to message types, which are written in
+	// place of their much longer type URLs.  Types absent from TypeIDs are
+	// written with type URLs.  If a type is assigned several IDs, writers use
+	// the smallest.
+	TypeIDs map[uint64]protoreflect.FullName
+}
+
+// resolver returns the effective message type resolver.
+func (o TaggedOptions) resolver() protoregistry.MessageTypeResolver {
+	if o.Resolver == nil {
+		return protoregistry.GlobalTypes
+	}
+	return o.Resolver
+}
+
+// typeIDs inverts o.TypeIDs.
+func (o TaggedOptions) typeIDs() map[protoreflect.FullName]uint64 {
+	ids := make(map[protoreflect.FullName]uint64, len(o.TypeIDs))
+	for id, name := range o.TypeIDs {
+		if prev, ok := ids[name]; !ok || id < prev {
+			ids[name] = id
+		}
+	}
+	return ids
+}
+
+// WriteTagged writes m to w as a tagged record bearing its type URL.  It
+// returns the total number of bytes written and any applicable error.
+func WriteTagged(w io.Writer, m proto.Message) (n int, err error) {
+	return TaggedOptions{}.WriteTagged(w, m)
+}
+
+// WriteTagged behaves like the package-level WriteTagged function but tags m
+// according to o.  Callers writing many records should prefer a TaggedWriter,
+// which inverts TypeIDs only once.
+func (o TaggedOptions) WriteTagged(w io.Writer, m proto.Message) (n int, err error) {
+	payload, err := appendTagged(nil, m, o.typeIDs())
+	if err != nil {
+		return 0, err
+	}
+	return WriteDelimitedBytes(w, payload)
+}
+
+// ReadTagged reads the next tagged record from r and returns it as a message
+// of the type it names, resolved through protoregistry.GlobalTypes.  The byte
+// count and error behavior are as per ReadDelimited.  A type that cannot be
+// resolved yields a *FrameError that matches protoregistry.NotFound under
+// errors.Is.
+func ReadTagged(r io.Reader) (m proto.Message, n int, err error) {
+	return TaggedOptions{}.ReadTagged(r)
+}
+
+// ReadTagged behaves like the package-level ReadTagged function but resolves
+// types according to o.
+func (o TaggedOptions) ReadTagged(r io.Reader) (m proto.Message, n int, err error) {
+	payload, n, err := ReadDelimitedBytes(r)
+	if err != nil {
+		return nil, n, err
+	}
+	m, err = o.unmarshalTagged(0, payload)
+	return m, n, err
+}
+
+// TaggedWriter writes tagged records through a Writer, which determines their
+// framing.
+type TaggedWriter struct {
+	w   *Writer
+	ids map[protoreflect.FullName]uint64
+	buf []byte
+}
+
+// NewTaggedWriter returns a TaggedWriter that writes records bearing type URLs
+// to w.
+func NewTaggedWriter(w *Writer) *TaggedWriter {
+	return TaggedOptions{}.NewTaggedWriter(w)
+}
+
+// NewTaggedWriter returns a TaggedWriter that tags records according to o.
+func (o TaggedOptions) NewTaggedWriter(w *Writer) *TaggedWriter {
+	return &TaggedWriter{w: w, ids: o.typeIDs()}
+}
+
+// Write encodes m as the next record as per Writer.Write.  The caller remains
+// responsible for flushing or closing the underlying Writer.
+func (w *TaggedWriter) Write(m proto.Message) error {
+	if w.w.closed {
+		return errWriterClosed
+	}
+	buf, err := appendTagged(w.buf[:0], m, w.ids)
+	if err != nil {
+		return err
+	}
+	w.buf = buf
+	return w.w.writeFrame(buf)
+}
+
+// TaggedReader reads tagged records through a Reader, which determines their
+// framing.
+type TaggedReader struct {
+	opts TaggedOptions
+	r    *Reader
+}
+
+// NewTaggedReader returns a TaggedReader that reads records from r, resolving
+// their types through protoregistry.GlobalTypes.
+func NewTaggedReader(r *Reader) *TaggedReader {
+	return TaggedOptions{}.NewTaggedReader(r)
+}
+
+// NewTaggedReader returns a TaggedReader that resolves types according to o.
+func (o TaggedOptions) NewTaggedReader(r *Reader) *TaggedReader {
+	return &TaggedReader{opts: o, r: r}
+}
+
+// Next reads the next record and returns it as a newly allocated message of
+// the type it names.  It returns io.EOF, unwrapped, once the stream ends
+// cleanly on a record boundary.  A type that cannot be resolved yields a
+// *FrameError that matches protoregistry.NotFound under errors.Is; the record
+// is consumed, so reading may continue past it.
+func (r *TaggedReader) Next() (proto.Message, error) {
+	offset := r.r.Offset()
+	payload, err := r.r.NextBytes()
+	if err != nil {
+		return nil, err
+	}
+	return r.opts.unmarshalTagged(offset, payload)
+}
+
+// appendTagged appends the envelope of m to b, tagged with its ID from ids if
+// it has one and with its type URL otherwise.
+func appendTagged(b []byte, m proto.Message, ids map[protoreflect.FullName]uint64) ([]byte, error) {
+	name := m.ProtoReflect().Descriptor().FullName()
+	if id, ok := ids[name]; ok {
+		b = protowire.AppendTag(b, taggedIDField, protowire.VarintType)
+		b = protowire.AppendVarint(b, id)
+	} else {
+		b = protowire.AppendTag(b, taggedURLField, protowire.BytesType)
+		b = protowire.AppendVarint(b, uint64(len(typeURLPrefix)+len(name)))
+		b = append(b, typeURLPrefix...)
+		b = append(b, name...)
+	}
+	b = protowire.AppendTag(b, taggedValueField, protowire.BytesType)
+	b = protowire.AppendVarint(b, uint64(proto.Size(m)))
+	return proto.MarshalOptions{UseCachedSize: true}.MarshalAppend(b, m)
+}
+
+// unmarshalTagged decodes the envelope payload of the record at offset into a
+// message of the type it names.
+func (o TaggedOptions) unmarshalTagged(offset int64, payload []byte) (proto.Message, error) {
+	mt, value, err := o.resolveTagged(payload)
+	if err != nil {
+		return nil, frameError(offset, StageUnmarshal, uint64(len(payload)), err)
+	}
+	m := mt.New().Interface()
+	opts := proto.UnmarshalOptions{}
+	if er, ok := o.resolver().(protoregistry.ExtensionTypeResolver); ok {
+		opts.Resolver = er
+	}
+	if err := opts.Unmarshal(value, m); err != nil {
+		return nil, frameError(offset, StageUnmarshal, uint64(len(payload)), err)
+	}
+	return m, nil
+}
+
+// resolveTagged parses an envelope, returning the type it names and the
+// encoded message it holds.
+func (o TaggedOptions) resolveTagged(b []byte) (protoreflect.MessageType, []byte, error) {
+	var (
+		url, value []byte
+		id         uint64
+		hasID      bool
+	)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+		switch {
+		case num == taggedURLField && typ == protowire.BytesType:
+			url, n = protowire.ConsumeBytes(b)
+		case num == taggedValueField && typ == protowire.BytesType:
+			value, n = protowire.ConsumeBytes(b)
+		case num == taggedIDField && typ == protowire.VarintType:
+			id, n = protowire.ConsumeVarint(b)
+			hasID = true
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return nil, nil, protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	switch {
+	case hasID:
+		name, ok := o.TypeIDs[id]
+		if !ok {
+			return nil, nil, fmt.Errorf("pbutil: unknown type ID %d: %w", id, protoregistry.NotFound)
+		}
+		mt, err := o.resolver().FindMessageByName(name)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pbutil: resolving %s: %w", name, err)
+		}
+		return mt, value, nil
+	case url != nil:
+		mt, err := o.resolver().FindMessageByURL(string(url))
+		if err != nil {
+			return nil, nil, fmt.Errorf("pbutil: resolving %s: %w", url, err)
+		}
+		return mt, value, nil
+	default:
+		return nil, nil, errUntagged
+	}
+}
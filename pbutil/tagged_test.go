@@ -0,0 +1,249 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/testing/protocmp"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// taggedData returns records of several types to interleave in a stream.
+func taggedData() []proto.Message {
+	return []proto.Message{
+		&testdata.Record{First: proto.Uint64(1)},
+		&timestamppb.Timestamp{Seconds: 42},
+		&testdata.Record{Third: proto.String("third")},
+		&durationpb.Duration{Nanos: 7},
+		&testdata.Record{},
+	}
+}
+
+// writeTagged writes data to a tagged stream with opts.
+func writeTagged(t *testing.T, opts TaggedOptions, data []proto.Message) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	tw := opts.NewTaggedWriter(w)
+	for i, msg := range data {
+		if err := tw.Write(msg); err != nil {
+			t.Fatalf("tw.Write(data[%d]) = %v, want nil", i, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+	return buf.Bytes()
+}
+
+func TestTaggedRoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		opts TaggedOptions
+	}{
+		{
+			name: "type URLs",
+		},
+		{
+			name: "type IDs",
+			opts: TaggedOptions{TypeIDs: map[uint64]protoreflect.FullName{
+				1: "testdata.Record",
+				2: "google.protobuf.Timestamp",
+			}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data := taggedData()
+			stream := writeTagged(t, test.opts, data)
+			r := test.opts.NewTaggedReader(NewReader(bytes.NewReader(stream)))
+			for i, want := range data {
+				got, err := r.Next()
+				if err != nil {
+					t.Fatalf("r.Next() for record %d = ?, %v; want ?, nil", i, err)
+				}
+				if !cmp.Equal(got, want, protocmp.Transform()) {
+					t.Errorf("r.Next() for record %d = %v, want %v", i, got, want)
+				}
+			}
+			if got, err := r.Next(); err != io.EOF {
+				t.Errorf("r.Next() at end = %v, %v; want nil, io.EOF", got, err)
+			}
+		})
+	}
+}
+
+func TestTaggedTypeIDsAreSmaller(t *testing.T) {
+	data := taggedData()
+	urls := writeTagged(t, TaggedOptions{}, data)
+	ids := writeTagged(t, TaggedOptions{TypeIDs: map[uint64]protoreflect.FullName{
+		1: "testdata.Record",
+		// A type assigned several IDs is written with the smallest.
+		3: "google.protobuf.Timestamp",
+		2: "google.protobuf.Timestamp",
+	}}, data)
+	if len(ids) >= len(urls) {
+		t.Errorf("stream with type IDs is %d bytes, want fewer than %d", len(ids), len(urls))
+	}
+	// The Timestamp is the second record; its envelope begins with type ID 2.
+	r := NewReader(bytes.NewReader(ids))
+	if err := r.Skip(); err != nil {
+		t.Fatalf("r.Skip() = %v, want nil", err)
+	}
+	payload, err := r.NextBytes()
+	if err != nil {
+		t.Fatalf("r.NextBytes() = ?, %v; want ?, nil", err)
+	}
+	if want := []byte{taggedIDField << 3, 2}; !bytes.HasPrefix(payload, want) {
+		t.Errorf("Timestamp envelope = %v, want prefix %v", payload, want)
+	}
+}
+
+func TestTaggedAnyCompatible(t *testing.T) {
+	data := taggedData()
+	r := NewReader(bytes.NewReader(writeTagged(t, TaggedOptions{}, data)))
+	for i, msg := range data {
+		var got anypb.Any
+		if err := r.Next(&got); err != nil {
+			t.Fatalf("r.Next(&any) for record %d = %v, want nil", i, err)
+		}
+		want, err := anypb.New(msg)
+		if err != nil {
+			t.Fatalf("anypb.New(data[%d]) = ?, %v; want ?, nil", i, err)
+		}
+		if !cmp.Equal(&got, want, protocmp.Transform()) {
+			t.Errorf("record %d as Any = %v, want %v", i, &got, want)
+		}
+	}
+}
+
+func TestTaggedUnresolved(t *testing.T) {
+	var reg protoregistry.Types
+	if err := reg.RegisterMessage((*testdata.Record)(nil).ProtoReflect().Type()); err != nil {
+		t.Fatalf("reg.RegisterMessage(Record) = %v, want nil", err)
+	}
+	ids := map[uint64]protoreflect.FullName{1: "testdata.Record", 2: "google.protobuf.Timestamp"}
+	for _, test := range []struct {
+		name  string
+		wopts TaggedOptions
+		ropts TaggedOptions
+	}{
+		{
+			name:  "type URL missing from resolver",
+			ropts: TaggedOptions{Resolver: &reg},
+		},
+		{
+			name:  "type ID missing from resolver",
+			wopts: TaggedOptions{TypeIDs: ids},
+			ropts: TaggedOptions{Resolver: &reg, TypeIDs: ids},
+		},
+		{
+			name:  "unknown type ID",
+			wopts: TaggedOptions{TypeIDs: ids},
+			ropts: TaggedOptions{TypeIDs: map[uint64]protoreflect.FullName{1: "testdata.Record"}},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			data := []proto.Message{
+				&timestamppb.Timestamp{Seconds: 1},
+				&testdata.Record{First: proto.Uint64(2)},
+			}
+			stream := writeTagged(t, test.wopts, data)
+			r := test.ropts.NewTaggedReader(NewReader(bytes.NewReader(stream)))
+			got, err := r.Next()
+			if !errors.Is(err, protoregistry.NotFound) {
+				t.Errorf("r.Next() = %v, %v; want nil, protoregistry.NotFound", got, err)
+			}
+			var fe *FrameError
+			if !errors.As(err, &fe) || fe.Offset != 0 || fe.Stage != StageUnmarshal {
+				t.Errorf("r.Next() = ?, %v; want *FrameError at offset 0 in StageUnmarshal", err)
+			}
+			// The unresolved record is consumed, so reading continues.
+			got, err = r.Next()
+			if err != nil {
+				t.Fatalf("r.Next() after unresolved record = ?, %v; want ?, nil", err)
+			}
+			if !cmp.Equal(got, data[1], protocmp.Transform()) {
+				t.Errorf("r.Next() after unresolved record = %v, want %v", got, data[1])
+			}
+		})
+	}
+}
+
+func TestReadWriteTagged(t *testing.T) {
+	var buf bytes.Buffer
+	data := taggedData()
+	var written int
+	for i, msg := range data {
+		n, err := WriteTagged(&buf, msg)
+		if err != nil {
+			t.Fatalf("WriteTagged(&buf, data[%d]) = ?, %v; want ?, nil", i, err)
+		}
+		written += n
+	}
+	if want := writeTagged(t, TaggedOptions{}, data); !bytes.Equal(buf.Bytes(), want) {
+		t.Errorf("WriteTagged output = %v, want %v as written by TaggedWriter", buf.Bytes(), want)
+	}
+	var read int
+	for i, want := range data {
+		got, n, err := ReadTagged(&buf)
+		if err != nil {
+			t.Fatalf("ReadTagged(&buf) for record %d = ?, ?, %v; want ?, ?, nil", i, err)
+		}
+		read += n
+		if !cmp.Equal(got, want, protocmp.Transform()) {
+			t.Errorf("ReadTagged(&buf) for record %d = %v, want %v", i, got, want)
+		}
+	}
+	if read != written {
+		t.Errorf("read %d bytes, want %d", read, written)
+	}
+	if got, n, err := ReadTagged(&buf); got != nil || n != 0 || err != io.EOF {
+		t.Errorf("ReadTagged(&buf) at end = %v, %d, %v; want nil, 0, io.EOF", got, n, err)
+	}
+}
+
+func TestTaggedMalformed(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		payload []byte
+		want    error
+	}{
+		{name: "empty envelope", payload: []byte{}, want: errUntagged},
+		{name: "value only", payload: []byte{taggedValueField<<3 | 2, 0}, want: errUntagged},
+		{name: "truncated", payload: []byte{taggedURLField<<3 | 2, 5, 'x'}, want: errAny},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if _, err := WriteDelimitedBytes(&buf, test.payload); err != nil {
+				t.Fatalf("WriteDelimitedBytes(&buf, %v) = ?, %v; want ?, nil", test.payload, err)
+			}
+			_, _, err := ReadTagged(&buf)
+			if !matchErr(err, test.want) {
+				t.Errorf("ReadTagged(%v) = ?, ?, %v; want ?, ?, %v", test.payload, err, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"io"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// TypedReader reads records of a single message type T from a
+// length-delimited stream.  T must be a pointer to a generated message type,
+// such as *mypb.Event.
+type TypedReader[T proto.Message] struct {
+	r  io.Reader
+	mt protoreflect.MessageType
+}
+
+// NewTypedReader returns a TypedReader that reads records of type T from r.
+func NewTypedReader[T proto.Message](r io.Reader) *TypedReader[T] {
+	return &TypedReader[T]{r: r, mt: messageType[T]()}
+}
+
+// Read allocates a new T and decodes the next record into it as per
+// ReadDelimited.  On error, the zero value of T is returned alongside the
+// number of bytes consumed.
+func (r *TypedReader[T]) Read() (m T, n int, err error) {
+	m = r.mt.New().Interface().(T)
+	n, err = ReadDelimited(r.r, m)
+	if err != nil {
+		var zero T
+		return zero, n, err
+	}
+	return m, n, nil
+}
+
+// TypedWriter writes records of a single message type T to a length-delimited
+// stream.
+type TypedWriter[T proto.Message] struct {
+	w io.Writer
+}
+
+// NewTypedWriter returns a TypedWriter that writes records of type T to w.
+func NewTypedWriter[T proto.Message](w io.Writer) *TypedWriter[T] {
+	return &TypedWriter[T]{w: w}
+}
+
+// Write encodes m as the next record as per WriteDelimited.
+func (w *TypedWriter[T]) Write(m T) (n int, err error) {
+	return WriteDelimited(w.w, m)
+}
+
+// messageType returns the message type of T, which must be a concrete message
+// type for which the nil value still reflects its type.
+func messageType[T proto.Message]() protoreflect.MessageType {
+	var zero T
+	return zero.ProtoReflect().Type()
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/testing/protocmp"
+)
+
+func TestTypedEndToEnd(t *testing.T) {
+	data := []*testdata.Record{
+		{First: proto.Uint64(1)},
+		{},
+		{Third: proto.String("third")},
+	}
+	var buf bytes.Buffer
+	w := NewTypedWriter[*testdata.Record](&buf)
+	var written int
+	for i, msg := range data {
+		n, err := w.Write(msg)
+		if err != nil {
+			t.Fatalf("w.Write(data[%d]) = ?, %v; want ?, nil", i, err)
+		}
+		written += n
+	}
+
+	r := NewTypedReader[*testdata.Record](&buf)
+	var read int
+	for i, want := range data {
+		got, n, err := r.Read()
+		if err != nil {
+			t.Fatalf("r.Read() for record %d = ?, ?, %v; want ?, ?, nil", i, err)
+		}
+		read += n
+		if !cmp.Equal(got, want, protocmp.Transform()) {
+			t.Errorf("r.Read() for record %d = %v, want %v", i, got, want)
+		}
+	}
+	if read != written {
+		t.Errorf("read %d bytes, want %d", read, written)
+	}
+	got, n, err := r.Read()
+	if got != nil || n != 0 || err != io.EOF {
+		t.Errorf("r.Read() at end = %v, %d, %v; want nil, 0, %v", got, n, err, io.EOF)
+	}
+}
+
+func TestTypedReaderTruncated(t *testing.T) {
+	data := []byte{5, 8}
+	r := NewTypedReader[*testdata.Record](bytes.NewReader(data))
+	got, n, err := r.Read()
+	if got != nil {
+		t.Errorf("r.Read() = %v, ?, ?; want nil, ?, ?", got)
+	}
+	if want := 2; n != want {
+		t.Errorf("r.Read() = ?, %d, ?; want ?, %d, ?", n, want)
+	}
+	if want := io.ErrUnexpectedEOF; !errors.Is(err, want) {
+		t.Errorf("r.Read() = ?, ?, %v; want ?, ?, %v", err, want)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bufio"
+	"io"
+	"math/bits"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// VerifyOptions configures Verify.
+type VerifyOptions struct {
+	// Framing describes the framing of the stream's records.
+	Framing ReadDelimitedOptions
+
+	// Type, if set, is the message type that every payload must decode as.
+	// Otherwise, payloads need only be well-formed at the top level of the
+	// wire format, since the types of nested messages are unknown.
+	Type protoreflect.MessageType
+
+	// CheckRequired reports records of Type that lack required fields.
+	CheckRequired bool
+}
+
+// VerifyReport summarizes the records that Verify examined.
+type VerifyReport struct {
+	// Records is the number of records that passed verification.  When
+	// verification fails, it is also the index of the offending record.
+	Records int64
+	// Bytes is the number of bytes occupied by the records that passed.
+	Bytes int64
+	// Sizes is a histogram of payload sizes in power-of-two buckets:
+	// Sizes[0] counts empty payloads and Sizes[i] counts payloads of at least
+	// 2^(i-1) and fewer than 2^i bytes.  It is no longer than necessary.
+	Sizes []int64
+}
+
+// Verify reads the length-delimited stream r to its end, checking every
+// record's prefix and payload without retaining them.  It returns a report of
+// the records that passed and, if a record failed, a *FrameError describing
+// the first failure.  Verification stops at the first failure, since the
+// framing of the records that follow a corrupt one cannot be trusted.  The
+// stream is read through an internal buffer, so r need not be buffered.
+func Verify(r io.Reader) (*VerifyReport, error) {
+	return VerifyOptions{}.Verify(r)
+}
+
+// Verify behaves like the package-level Verify function but checks records
+// according to o.
+func (o VerifyOptions) Verify(r io.Reader) (*VerifyReport, error) {
+	rd := o.Framing.NewReader(bufio.NewReader(r))
+	unmarshal := proto.UnmarshalOptions{AllowPartial: !o.CheckRequired}
+	report := new(VerifyReport)
+	for {
+		offset := rd.Offset()
+		payload, err := rd.NextBytes()
+		if err == io.EOF {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		if o.Type != nil {
+			err = unmarshal.Unmarshal(payload, o.Type.New().Interface())
+		} else {
+			err = validateWire(payload)
+		}
+		if err != nil {
+			return report, frameError(offset, StageUnmarshal, uint64(len(payload)), err)
+		}
+		report.Records++
+		report.Bytes = rd.Offset()
+		bucket := bits.Len(uint(len(payload)))
+		for len(report.Sizes) <= bucket {
+			report.Sizes = append(report.Sizes, 0)
+		}
+		report.Sizes[bucket]++
+	}
+}
+
+// validateWire reports whether b is a well-formed sequence of fields.
+func validateWire(b []byte) error {
+	for len(b) > 0 {
+		_, _, n := protowire.ConsumeField(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestVerify(t *testing.T) {
+	var good bytes.Buffer
+	for _, msg := range []*testdata.Record{
+		{},                           // 0 bytes
+		{First: proto.Uint64(1)},     // 2 bytes
+		{Third: proto.String("abc")}, // 5 bytes
+		{Third: proto.String(strings.Repeat("x", 8))}, // 10 bytes
+	} {
+		if _, err := WriteDelimited(&good, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var required bytes.Buffer
+	if _, err := WriteDelimited(&required, &testdata.Record{Third: proto.String("x")}); err != nil {
+		t.Fatal(err)
+	}
+	requiredType := (*testdata.Required)(nil).ProtoReflect().Type()
+	for _, test := range []struct {
+		name   string
+		opts   VerifyOptions
+		input  []byte
+		report *VerifyReport
+		err    error
+		offset int64
+		stage  Stage
+	}{
+		{
+			name:   "empty",
+			report: &VerifyReport{},
+		},
+		{
+			name:   "well-formed",
+			input:  good.Bytes(),
+			report: &VerifyReport{Records: 4, Bytes: int64(good.Len()), Sizes: []int64{1, 0, 1, 1, 1}},
+		},
+		{
+			name:   "well-formed with type",
+			opts:   VerifyOptions{Type: (*testdata.Record)(nil).ProtoReflect().Type()},
+			input:  good.Bytes(),
+			report: &VerifyReport{Records: 4, Bytes: int64(good.Len()), Sizes: []int64{1, 0, 1, 1, 1}},
+		},
+		{
+			name:   "truncated payload",
+			input:  append(good.Bytes()[:good.Len():good.Len()], 5, 8),
+			report: &VerifyReport{Records: 4, Bytes: int64(good.Len()), Sizes: []int64{1, 0, 1, 1, 1}},
+			err:    io.ErrUnexpectedEOF,
+			offset: int64(good.Len()),
+			stage:  StagePayload,
+		},
+		{
+			name:   "malformed wire format",
+			input:  []byte{0, 2, 8, 0xff},
+			report: &VerifyReport{Records: 1, Bytes: 1, Sizes: []int64{1}},
+			err:    errAny,
+			offset: 1,
+			stage:  StageUnmarshal,
+		},
+		{
+			name:   "missing required field ignored",
+			opts:   VerifyOptions{Type: requiredType},
+			input:  required.Bytes(),
+			report: &VerifyReport{Records: 1, Bytes: int64(required.Len()), Sizes: []int64{0, 0, 1}},
+		},
+		{
+			name:   "missing required field",
+			opts:   VerifyOptions{Type: requiredType, CheckRequired: true},
+			input:  required.Bytes(),
+			report: &VerifyReport{},
+			err:    errAny,
+			stage:  StageUnmarshal,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			report, err := test.opts.Verify(bytes.NewReader(test.input))
+			if diff := cmp.Diff(test.report, report); diff != "" {
+				t.Errorf("Verify(%v) report diff (-want +got):\n%s", test.input, diff)
+			}
+			if test.err == nil {
+				if err != nil {
+					t.Errorf("Verify(%v) = ?, %v; want ?, nil", test.input, err)
+				}
+				return
+			}
+			if !matchErr(err, test.err) {
+				t.Errorf("Verify(%v) = ?, %v; want ?, %v", test.input, err, test.err)
+			}
+			var fe *FrameError
+			if !errors.As(err, &fe) {
+				t.Fatalf("Verify(%v) = ?, %v; want *FrameError", test.input, err)
+			}
+			if fe.Offset != test.offset || fe.Stage != test.stage {
+				t.Errorf("Verify(%v) failed at offset %d in %v, want offset %d in %v", test.input, fe.Offset, fe.Stage, test.offset, test.stage)
+			}
+		})
+	}
+}
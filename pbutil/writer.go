@@ -0,0 +1,153 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+var errWriterClosed = errors.New("pbutil: write to closed Writer")
+
+// Writer encodes a sequence of messages to a length-delimited stream.  Records
+// are staged in an internal buffer, so a Writer issues few, large writes to
+// the underlying io.Writer regardless of how small the records are.  The
+// output is byte-for-byte identical to that of successive WriteDelimited
+// calls with the same options.
+//
+// Callers must call Flush or Close once they are done writing to ensure that
+// all records reach the underlying io.Writer.
+type Writer struct {
+	opts   WriteDelimitedOptions
+	cw     ctxWriter
+	bw     *bufio.Writer
+	buf    []byte
+	zbuf   []byte
+	offset int64
+	index  int64
+	closed bool
+}
+
+// NewWriter returns a Writer with a default-sized buffer that writes records
+// to w.
+func NewWriter(w io.Writer) *Writer {
+	return WriteDelimitedOptions{}.NewWriter(w)
+}
+
+// NewWriter returns a Writer with a default-sized buffer that writes records
+// to w according to o.
+func (o WriteDelimitedOptions) NewWriter(w io.Writer) *Writer {
+	wr := &Writer{opts: o, cw: ctxWriter{w: w}}
+	wr.bw = bufio.NewWriter(&wr.cw)
+	return wr
+}
+
+// NewWriterSize returns a Writer with a buffer of at least size bytes that
+// writes records to w.  Records larger than the buffer are passed through to
+// w directly.
+func NewWriterSize(w io.Writer, size int) *Writer {
+	return WriteDelimitedOptions{}.NewWriterSize(w, size)
+}
+
+// NewWriterSize returns a Writer with a buffer of at least size bytes that
+// writes records to w according to o.
+func (o WriteDelimitedOptions) NewWriterSize(w io.Writer, size int) *Writer {
+	wr := &Writer{opts: o, cw: ctxWriter{w: w}}
+	wr.bw = bufio.NewWriterSize(&wr.cw, size)
+	return wr
+}
+
+// Write encodes m as the next record in the stream.  If m fails to marshal,
+// nothing is written and the Writer remains usable.  Errors from the
+// underlying io.Writer are sticky: once one occurs, every later Write, Flush,
+// and Close returns it.
+func (w *Writer) Write(m proto.Message) error {
+	if w.closed {
+		return errWriterClosed
+	}
+	buf, err := proto.MarshalOptions{}.MarshalAppend(w.buf[:0], m)
+	if err != nil {
+		return err
+	}
+	w.buf = buf
+	return w.writeFrame(buf)
+}
+
+// writeFrame writes payload and its varint prefix to the buffer.
+func (w *Writer) writeFrame(payload []byte) error {
+	if w.opts.Compressed {
+		stored, err := compressPayload(w.zbuf[:0], payload, w.opts.Codec)
+		if err != nil {
+			return err
+		}
+		w.zbuf, payload = stored, stored
+	}
+	// This mirrors WriteDelimitedBytes, but writing to the concrete
+	// *bufio.Writer keeps the header array from escaping to the heap.
+	var arr [binary.MaxVarintLen64]byte
+	hdr := protowire.AppendVarint(arr[:0], uint64(len(payload)))
+	n, err := w.bw.Write(hdr)
+	w.offset += int64(n)
+	if err != nil {
+		return err
+	}
+	n, err = w.bw.Write(payload)
+	w.offset += int64(n)
+	if err != nil {
+		return err
+	}
+	if w.opts.Checksum {
+		var trailer [checksumLen]byte
+		n, err = w.bw.Write(appendChecksum(trailer[:0], payload))
+		w.offset += int64(n)
+		if err != nil {
+			return err
+		}
+	}
+	w.index++
+	return nil
+}
+
+// Flush writes any buffered records to the underlying io.Writer.
+func (w *Writer) Flush() error {
+	return w.bw.Flush()
+}
+
+// Close flushes any buffered records and prevents further writes.  It does not
+// close the underlying io.Writer.  Calling Close more than once returns the
+// result of the flush each time.
+func (w *Writer) Close() error {
+	w.closed = true
+	return w.bw.Flush()
+}
+
+// SetCodec changes the codec applied to subsequent records of a Writer created
+// with WriteDelimitedOptions.Compressed.  A stream may freely mix codecs.
+func (w *Writer) SetCodec(c Codec) {
+	w.opts.Codec = c
+}
+
+// Offset returns the number of bytes accepted by the Writer, including any
+// that are still buffered and have yet to be flushed.
+func (w *Writer) Offset() int64 { return w.offset }
+
+// Index returns the zero-based index of the next record to be written, which is
+// also the number of records written so far.
+func (w *Writer) Index() int64 { return w.index }
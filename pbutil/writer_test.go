@@ -0,0 +1,135 @@
+// Copyright 2026 Matt T. Proud
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pbutil
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/matttproud/golang_protobuf_extensions/v2/testdata"
+	"google.golang.org/protobuf/proto"
+)
+
+// countingWriter records how many times Write is called.
+type countingWriter struct {
+	bytes.Buffer
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.Buffer.Write(p)
+}
+
+func TestWriterMatchesWriteDelimited(t *testing.T) {
+	data := []proto.Message{
+		&testdata.Record{First: proto.Uint64(1)},
+		new(testdata.Record),
+		&testdata.Record{Third: proto.String("third")},
+	}
+	var want bytes.Buffer
+	for i, msg := range data {
+		if _, err := WriteDelimited(&want, msg); err != nil {
+			t.Fatalf("WriteDelimited(buf, data[%d]) = ?, %v; want ?, nil", i, err)
+		}
+	}
+
+	var got countingWriter
+	w := NewWriter(&got)
+	for i, msg := range data {
+		if err := w.Write(msg); err != nil {
+			t.Fatalf("w.Write(data[%d]) = %v, want nil", i, err)
+		}
+	}
+	if got.writes != 0 {
+		t.Errorf("before Flush, underlying writer saw %d writes, want 0", got.writes)
+	}
+	if got, want := w.Offset(), int64(want.Len()); got != want {
+		t.Errorf("w.Offset() = %d, want %d", got, want)
+	}
+	if got, want := w.Index(), int64(len(data)); got != want {
+		t.Errorf("w.Index() = %d, want %d", got, want)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+	if got, want := got.writes, 1; got != want {
+		t.Errorf("after Close, underlying writer saw %d writes, want %d", got, want)
+	}
+	if !cmp.Equal(got.Bytes(), want.Bytes()) {
+		t.Errorf("w wrote %v, want %v", got.Bytes(), want.Bytes())
+	}
+}
+
+func TestWriterMarshalErr(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if got, want := w.Write(new(testdata.Required)), proto.Error; !errors.Is(got, want) {
+		t.Errorf("w.Write(new(testdata.Required)) = %v, want %v", got, want)
+	}
+	if err := w.Write(new(testdata.Record)); err != nil {
+		t.Errorf("w.Write(new(testdata.Record)) = %v, want nil", err)
+	}
+	if err := w.Flush(); err != nil {
+		t.Fatalf("w.Flush() = %v, want nil", err)
+	}
+	if got, want := buf.Bytes(), []byte{0}; !cmp.Equal(got, want) {
+		t.Errorf("w wrote %v, want %v", got, want)
+	}
+	if got, want := w.Index(), int64(1); got != want {
+		t.Errorf("w.Index() = %d, want %d", got, want)
+	}
+}
+
+func TestWriterWriteErr(t *testing.T) {
+	w := NewWriter(cantWrite{})
+	if err := w.Write(new(testdata.Record)); err != nil {
+		t.Fatalf("w.Write(msg) = %v, want nil", err)
+	}
+	if got, want := w.Flush(), errWrite; !errors.Is(got, want) {
+		t.Errorf("w.Flush() = %v, want %v", got, want)
+	}
+	if got, want := w.Write(new(testdata.Record)), errWrite; !errors.Is(got, want) {
+		t.Errorf("after failed Flush, w.Write(msg) = %v, want %v", got, want)
+	}
+}
+
+func TestWriterClosed(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	if err := w.Close(); err != nil {
+		t.Fatalf("w.Close() = %v, want nil", err)
+	}
+	if got, want := w.Write(new(testdata.Record)), errWriterClosed; !errors.Is(got, want) {
+		t.Errorf("after Close, w.Write(msg) = %v, want %v", got, want)
+	}
+	if got := buf.Len(); got != 0 {
+		t.Errorf("after Close, w wrote %d bytes, want 0", got)
+	}
+}
+
+func TestWriterSizePassthrough(t *testing.T) {
+	var buf countingWriter
+	w := NewWriterSize(&buf, 16)
+	msg := &testdata.Record{Third: proto.String("this record is larger than the buffer")}
+	if err := w.Write(msg); err != nil {
+		t.Fatalf("w.Write(msg) = %v, want nil", err)
+	}
+	if buf.writes == 0 {
+		t.Errorf("record larger than buffer was not passed through to underlying writer")
+	}
+}